@@ -1,17 +1,46 @@
 package controller
 
+// Controller holds state shared between the game and its scenes. Screen
+// sizes are framebuffer pixels, window sizes are the screen coordinates
+// cursor positions use. They differ on HiDPI displays.
 type Controller struct {
 	ScreenWidth  int
 	ScreenHeight int
+	WindowWidth  int
+	WindowHeight int
 	AspectRatio  float32
 	IsReady      bool
+	DeltaTime    float64
 }
 
 func NewGameController(width int, height int) *Controller {
 	return &Controller{
 		ScreenWidth:  width,
 		ScreenHeight: height,
+		WindowWidth:  width,
+		WindowHeight: height,
 		AspectRatio:  float32(width) / float32(height),
 		IsReady:      false,
 	}
 }
+
+// Resize updates the framebuffer size. A minimized window reports 0x0, the
+// aspect ratio is kept then.
+func (c *Controller) Resize(width, height int) {
+	c.ScreenWidth, c.ScreenHeight = width, height
+	if width > 0 && height > 0 {
+		c.AspectRatio = float32(width) / float32(height)
+	}
+}
+
+func (c *Controller) ResizeWindow(width, height int) {
+	c.WindowWidth, c.WindowHeight = width, height
+}
+
+// PixelRatio is how many framebuffer pixels there are per window unit.
+func (c *Controller) PixelRatio() float64 {
+	if c.WindowWidth == 0 {
+		return 1
+	}
+	return float64(c.ScreenWidth) / float64(c.WindowWidth)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"remnant/pkg/capture"
 	"remnant/pkg/game"
+	"remnant/pkg/program"
 	"remnant/pkg/scene"
+	"remnant/pkg/sdf"
 	"runtime"
+	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -16,6 +21,21 @@ const (
 	windowHeight = 450
 )
 
+// settings collects repeated -set name=value flags.
+type settings []string
+
+func (s *settings) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *settings) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("want name=value, got %q", value)
+	}
+	*s = append(*s, value)
+	return nil
+}
+
 // Initialization
 
 func init() {
@@ -23,19 +43,87 @@ func init() {
 	runtime.LockOSThread()
 }
 func main() {
+	recordPath := flag.String("record", "", "record the camera path to this JSON file")
+	playPath := flag.String("play", "", "play back a camera path from this JSON file")
+	loop := flag.Bool("loop", false, "loop camera path playback")
+	sceneFile := flag.String("scene", "", "load the scene from this JSON scene file")
+	hotReload := flag.Bool("watch", true, "reload shaders and the scene file when they change on disk")
+	presetName := flag.String("preset", "high", "render quality preset: low, medium, high or ultra")
+	dynamicRes := flag.Bool("dynamic-res", false, "scale the render resolution to hold the target frame rate")
+	targetFPS := flag.Float64("target-fps", 60, "frame rate dynamic resolution aims for")
+	viewFile := flag.String("view", "", "reproduce the scene and camera a screenshot PNG was taken with")
+	captureDir := flag.String("capture", "", "capture every frame into this directory at a fixed frame rate")
+	captureFPS := flag.Int("capture-fps", 60, "frame rate of the captured sequence")
+	captureFormat := flag.String("capture-format", "png", "capture format: png (numbered files) or y4m (one raw video stream)")
+	captureFrames := flag.Int("capture-frames", 0, "quit after capturing this many frames, 0 captures until the window is closed")
+	width := flag.Int("width", windowWidth, "window width in screen coordinates")
+	height := flag.Int("height", windowHeight, "window height in screen coordinates")
+	fullscreen := flag.Bool("fullscreen", false, "start fullscreen")
+	fullscreenMode := flag.String("fullscreen-mode", "borderless", "what F11 switches to: borderless or fullscreen (exclusive)")
+	monitor := flag.Int("monitor", 0, "index of the monitor to go fullscreen on")
+	vsync := flag.Bool("vsync", true, "wait for the display refresh when swapping buffers")
+	fpsCap := flag.Float64("fps-cap", 0, "limit the frame rate, 0 leaves it uncapped")
+	traceFile := flag.String("trace", "", "profile every frame and write a Chrome trace JSON to this file on exit")
+	statsFile := flag.String("stats", "", "write every frame time to this CSV file on exit")
+	taa := flag.Bool("taa", true, "accumulate jittered frames for temporal anti-aliasing")
+	showHUD := flag.Bool("hud", true, "show the on-screen HUD, U toggles it")
+	debugView := flag.String("debug-view", "off", "ray marcher debug view: off, steps, distance, normals, material, shadow, fog or miss")
+	configFile := flag.String("config", "remnant.cfg", "load console variables from this file and save them to it on exit, empty for neither")
+	var set settings
+	flag.Var(&set, "set", "set a console variable, name=value, after the config file is loaded; repeatable")
+	flag.Parse()
+
+	preset, ok := program.PresetByName(*presetName)
+	if !ok {
+		log.Fatalf("unknown preset %q", *presetName)
+	}
+	if *targetFPS <= 0 {
+		log.Fatal("-target-fps must be positive")
+	}
+	mode, err := game.ParseWindowMode(*fullscreenMode)
+	if err != nil || mode == game.Windowed {
+		log.Fatalf("-fullscreen-mode must be borderless or fullscreen, got %q", *fullscreenMode)
+	}
+	debug, err := sdf.ParseDebugMode(*debugView)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Validate the scene file before opening a window
+	var sceneDesc *scene.File
+	if *sceneFile != "" {
+		desc, err := scene.LoadFile(*sceneFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sceneDesc = desc
+	}
+
+	var view *capture.Metadata
+	if *viewFile != "" {
+		meta, err := capture.ReadMetadata(*viewFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		view = meta
+	}
+
 	// Create the window
 	if err := glfw.Init(); err != nil {
 		panic(fmt.Errorf("could not initialize glfw: %v", err))
 	}
 
 	// OpenGL version 4.1 Core Profile
-	glfw.WindowHint(glfw.Resizable, glfw.False)
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	// size the window in logical units on HiDPI displays, the framebuffer
+	// gets the extra pixels
+	glfw.WindowHint(glfw.ScaleToMonitor, glfw.True)
 	glfw.WindowHint(glfw.ContextVersionMajor, 4)
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 
-	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Remnant", nil, nil)
+	window, err := glfw.CreateWindow(*width, *height, "Remnant", nil, nil)
 	if err != nil {
 		panic(fmt.Errorf("could not create opengl renderer: %v", err))
 	}
@@ -50,15 +138,111 @@ func main() {
 
 	// Run the Game
 	game := game.NewGame(window)
+	game.Quality = preset
+	game.DynamicResolution = *dynamicRes
+	game.TargetFPS = *targetFPS
+	game.Temporal = *taa
+	game.Debug = debug
+	game.HUD = *showHUD
+	game.FullscreenMode = mode
+	game.VSync = *vsync
+	game.FrameCap = *fpsCap
+	if *statsFile != "" {
+		game.RecordFrameTimes(*statsFile)
+	}
+	if *traceFile != "" {
+		game.RecordTrace(*traceFile)
+	}
+	game.Monitor = *monitor
 
-	scene := scene.NewSceneB(game.Controller)
+	// the command line wins over the config file: flags given explicitly
+	// set the console variables they overlap with, then -set
+	game.ConfigFile = *configFile
+	if *configFile != "" {
+		if err := game.Console.LoadConfig(*configFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		var err error
+		switch f.Name {
+		case "taa":
+			err = game.Console.Set("r_taa", f.Value.String())
+		case "hud":
+			err = game.Console.Set("hud", f.Value.String())
+		case "preset":
+			// the preset's own step count
+			err = game.Console.Set("r_steps", "0")
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	})
+	for _, s := range set {
+		name, value, _ := strings.Cut(s, "=")
+		if err := game.Console.Set(name, value); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	err = game.Load(scene)
+	game.Scenes.Register("a", func() scene.Scene { return scene.NewSceneA(game.Controller) })
+	game.Scenes.Register("b", func() scene.Scene { return scene.NewSceneB(game.Controller) })
+	if sceneDesc != nil {
+		if err := game.RegisterScene(sceneDesc); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if view != nil {
+		err = game.Scenes.LoadNamed(view.Scene)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game.ShowView(view)
+	} else if sceneDesc != nil {
+		game.Scenes.Push(sceneDesc.Build(game.Controller))
+	} else {
+		game.Scenes.Push(scene.NewSceneB(game.Controller))
+	}
+
+	err = game.Load()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = game.Run(window, scene)
+	if *fullscreen {
+		err = game.SetWindowMode(mode, *monitor)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *hotReload {
+		err = game.Watch(*sceneFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *recordPath != "" {
+		game.RecordCameraPath(*recordPath, 0.25)
+	}
+	if *playPath != "" {
+		err = game.PlayCameraPath(*playPath, *loop)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *captureDir != "" {
+		seq, err := capture.NewSequence(*captureDir, *captureFPS, capture.SequenceFormat(*captureFormat))
+		if err != nil {
+			log.Fatal(err)
+		}
+		game.RecordSequence(seq, *captureFrames)
+	}
+
+	err = game.Run()
 	if err != nil {
 		log.Fatal(err)
 	}
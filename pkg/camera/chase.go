@@ -0,0 +1,77 @@
+package camera
+
+import (
+	"math"
+	"remnant/pkg/physics"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Chase follows behind a body on a damped spring, lagging slightly when the
+// body accelerates or turns.
+type Chase struct {
+	Body      *physics.RigidBody
+	Distance  float64 `ui:"1,64"`
+	Height    float64
+	LookAhead float64
+	Stiffness float64
+	// DampingRatio scales the spring's damping, 1 is critically damped at
+	// any Stiffness, below that the camera overshoots.
+	DampingRatio float64
+	FOV          float32 `ui:"10,120"`
+
+	position *mat.VecDense
+	velocity *mat.VecDense
+	up       *mat.VecDense
+}
+
+func NewChase(body *physics.RigidBody, distance, height float64, fov float32) *Chase {
+	return &Chase{
+		Body:         body,
+		Distance:     distance,
+		Height:       height,
+		LookAhead:    distance,
+		Stiffness:    40,
+		DampingRatio: 1,
+		FOV:          fov,
+	}
+}
+
+// Snap places the camera at its rest position, skipping the spring.
+func (c *Chase) Snap() {
+	c.position = c.desiredPosition()
+	c.velocity = mat.NewVecDense(3, nil)
+	c.up = c.Body.Up()
+}
+
+func (c *Chase) Update(dt float64) *Pose {
+	if c.position == nil {
+		c.Snap()
+	}
+
+	// semi-implicit euler on a spring towards the rest position
+	accel := mat.NewVecDense(3, nil)
+	accel.SubVec(c.desiredPosition(), c.position)
+	accel.ScaleVec(c.Stiffness, accel)
+	damping := c.DampingRatio * 2 * math.Sqrt(c.Stiffness)
+	accel.AddScaledVec(accel, -damping, c.velocity)
+
+	c.velocity.AddScaledVec(c.velocity, dt, accel)
+	c.position.AddScaledVec(c.position, dt, c.velocity)
+
+	lerp(c.up, c.up, c.Body.Up(), 1-math.Exp(-c.Stiffness*0.25*dt))
+
+	target := mat.NewVecDense(3, nil)
+	target.AddScaledVec(c.Body.Position, c.LookAhead, c.Body.Forward())
+	direction := mat.NewVecDense(3, nil)
+	direction.SubVec(target, c.position)
+
+	return NewPose(c.position, direction, c.up, c.FOV)
+}
+
+func (c *Chase) desiredPosition() *mat.VecDense {
+	p := mat.NewVecDense(3, nil)
+	p.AddScaledVec(c.Body.Position, -c.Distance, c.Body.Forward())
+	p.AddScaledVec(p, c.Height, c.Body.Up())
+	return p
+}
@@ -0,0 +1,29 @@
+package camera
+
+import (
+	"remnant/pkg/physics"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Cockpit rigidly attaches the camera to a body, at Offset in body space.
+type Cockpit struct {
+	Body   *physics.RigidBody
+	Offset *mat.VecDense
+	FOV    float32 `ui:"10,120"`
+}
+
+func NewCockpit(body *physics.RigidBody, fov float32) *Cockpit {
+	return &Cockpit{
+		Body:   body,
+		Offset: mat.NewVecDense(3, []float64{0, 0, 0}),
+		FOV:    fov,
+	}
+}
+
+func (c *Cockpit) Update(dt float64) *Pose {
+	position := physics.RotateVectorByQuaternion(c.Offset, c.Body.Orientation)
+	position.AddVec(position, c.Body.Position)
+
+	return NewPose(position, c.Body.Forward(), c.Body.Up(), c.FOV)
+}
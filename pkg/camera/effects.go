@@ -0,0 +1,149 @@
+package camera
+
+import (
+	"math"
+	"remnant/pkg/physics"
+	"remnant/pkg/program"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// Effects layers speed feedback on top of a base camera: FOV kick with
+// velocity and boost, trauma shake, acceleration lean and head bob. Apply
+// writes into a separate view camera, so the base orientation that input and
+// controllers work with is never disturbed.
+type Effects struct {
+	Body *physics.RigidBody
+
+	// FOV widens by FOVPerSpeed degrees per unit of speed, up to MaxFOVKick,
+	// plus BoostFOV while Boosting.
+	FOVPerSpeed float64 `ui:"0,2"`
+	MaxFOVKick  float64 `ui:"0,45"`
+	BoostFOV    float64 `ui:"0,30"`
+	Boosting    bool
+
+	// Shake is trauma^2 * MaxShake radians of perlin noise at ShakeFrequency
+	// hz. Trauma decays by TraumaDecay per second.
+	MaxShake       float64
+	ShakeFrequency float64
+	TraumaDecay    float64
+
+	// Lean rolls and pitches the view by LeanPerAccel radians per unit of
+	// acceleration, up to MaxLean.
+	LeanPerAccel float64
+	MaxLean      float64
+
+	// Head bob moves the view up and down by BobAmplitude at full BobSpeed.
+	BobAmplitude float64
+	BobFrequency float64
+	BobSpeed     float64
+
+	// Response is how quickly FOV and lean follow their targets, per second.
+	Response float64
+
+	trauma    float64
+	time      float64
+	bobPhase  float64
+	fovKick   float64
+	leanRoll  float64
+	leanPitch float64
+	view      *program.Camera
+}
+
+func NewEffects(body *physics.RigidBody) *Effects {
+	return &Effects{
+		Body:           body,
+		FOVPerSpeed:    0.5,
+		MaxFOVKick:     20,
+		BoostFOV:       10,
+		MaxShake:       0.08,
+		ShakeFrequency: 15,
+		TraumaDecay:    0.8,
+		LeanPerAccel:   0.02,
+		MaxLean:        0.15,
+		BobAmplitude:   0.05,
+		BobFrequency:   1.5,
+		BobSpeed:       10,
+		Response:       4,
+		view:           program.NewCamera(mat.NewVecDense(3, nil), 60),
+	}
+}
+
+// AddTrauma adds to the shake amount, which is clamped to [0, 1].
+func (e *Effects) AddTrauma(amount float64) {
+	e.trauma = math.Max(0, math.Min(1, e.trauma+amount))
+}
+
+func (e *Effects) Trauma() float64 {
+	return e.trauma
+}
+
+func (e *Effects) Update(dt float64) {
+	e.time += dt
+	e.trauma = math.Max(0, e.trauma-e.TraumaDecay*dt)
+
+	speed := e.Body.Velocity.Norm(2)
+	blend := 1 - math.Exp(-e.Response*dt)
+
+	kick := math.Min(speed*e.FOVPerSpeed, e.MaxFOVKick)
+	if e.Boosting {
+		kick += e.BoostFOV
+	}
+	e.fovKick += (kick - e.fovKick) * blend
+
+	// lean away from sideways acceleration, tip back when speeding up
+	accel := e.Body.Acceleration
+	side := mat.Dot(accel, e.Body.Right())
+	forward := mat.Dot(accel, e.Body.Forward())
+	e.leanRoll += (clamp(-side*e.LeanPerAccel, e.MaxLean) - e.leanRoll) * blend
+	e.leanPitch += (clamp(-forward*e.LeanPerAccel, e.MaxLean) - e.leanPitch) * blend
+
+	e.bobPhase += dt * e.BobFrequency * 2 * math.Pi * math.Min(speed/e.BobSpeed, 1)
+}
+
+// Apply returns base with the effects layered on. The returned camera is
+// reused between calls.
+func (e *Effects) Apply(base *program.Camera) *program.Camera {
+	e.view.Pos.CopyVec(base.Pos)
+	e.view.FOV = base.FOV + float32(e.fovKick)
+
+	shake := e.trauma * e.trauma * e.MaxShake
+	yaw := shake * perlin(e.time*e.ShakeFrequency, 0)
+	pitch := shake*perlin(e.time*e.ShakeFrequency, 1) + e.leanPitch
+	roll := shake*perlin(e.time*e.ShakeFrequency, 2) + e.leanRoll
+
+	q := quat.Mul(physics.CreateRotationQuaternion(yaw, mat.NewVecDense(3, []float64{0, 1, 0})),
+		physics.CreateRotationQuaternion(pitch, mat.NewVecDense(3, []float64{1, 0, 0})))
+	q = quat.Mul(q, physics.CreateRotationQuaternion(roll, mat.NewVecDense(3, []float64{0, 0, 1})))
+	e.view.Orientation = physics.NormalizeQuaternion(quat.Mul(base.Orientation, q))
+
+	speed := math.Min(e.Body.Velocity.Norm(2)/e.BobSpeed, 1)
+	bob := math.Sin(e.bobPhase) * e.BobAmplitude * speed
+	e.view.Pos.AddScaledVec(e.view.Pos, bob, base.Up())
+
+	return e.view
+}
+
+func clamp(x, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, x))
+}
+
+// perlin is 1D gradient noise in [-1, 1]; seed picks an independent channel.
+func perlin(x float64, seed int) float64 {
+	i := math.Floor(x)
+	f := x - i
+	g0 := gradient(int64(i), seed)
+	g1 := gradient(int64(i)+1, seed)
+	u := f * f * f * (f*(f*6-15) + 10)
+	// gradients are in [-1, 1], so the raw result lies in [-0.5, 0.5]
+	return 2 * (g0*f + u*(g1*(f-1)-g0*f))
+}
+
+func gradient(i int64, seed int) float64 {
+	h := uint64(i)*0x9E3779B97F4A7C15 ^ uint64(seed)*0xC2B2AE3D27D4EB4F
+	h ^= h >> 31
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 29
+	return float64(h>>11)/float64(1<<53)*2 - 1
+}
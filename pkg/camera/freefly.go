@@ -0,0 +1,63 @@
+package camera
+
+import (
+	"remnant/pkg/physics"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// FreeFly is a debug camera that moves independently of any body.
+type FreeFly struct {
+	Position  *mat.VecDense
+	Direction *mat.VecDense
+	Up        *mat.VecDense
+	FOV       float32 `ui:"10,120"`
+	Speed     float64 `ui:"1,64"`
+
+	move *mat.VecDense
+}
+
+func NewFreeFly(position *mat.VecDense, fov float32, speed float64) *FreeFly {
+	f := &FreeFly{
+		Position:  mat.NewVecDense(3, nil),
+		Direction: mat.NewVecDense(3, []float64{0, 0, 1}),
+		Up:        mat.NewVecDense(3, []float64{0, 1, 0}),
+		FOV:       fov,
+		Speed:     speed,
+		move:      mat.NewVecDense(3, nil),
+	}
+	f.Position.CopyVec(position)
+	return f
+}
+
+func (f *FreeFly) Rotate(yaw, pitch float64) {
+	qx := physics.CreateRotationQuaternion(yaw, f.Up)
+	qy := physics.CreateRotationQuaternion(pitch, physics.Cross(f.Up, f.Direction))
+	f.rotate(quat.Mul(qx, qy))
+}
+
+func (f *FreeFly) Roll(rad float64) {
+	f.rotate(physics.CreateRotationQuaternion(rad, f.Direction))
+}
+
+// Move sets the world space direction to travel in during the next update.
+func (f *FreeFly) Move(direction *mat.VecDense) {
+	f.move.CopyVec(direction)
+}
+
+func (f *FreeFly) Update(dt float64) *Pose {
+	f.Position.AddScaledVec(f.Position, f.Speed*dt, f.move)
+	f.move.Zero()
+
+	return NewPose(f.Position, f.Direction, f.Up, f.FOV)
+}
+
+func (f *FreeFly) rotate(q quat.Number) {
+	f.Direction = physics.RotateVectorByQuaternion(f.Direction, q)
+	f.Up = physics.RotateVectorByQuaternion(f.Up, q)
+
+	// keep the basis orthonormal as rotations accumulate
+	p := NewPose(f.Position, f.Direction, f.Up, f.FOV)
+	f.Direction, f.Up = p.Direction, p.Up
+}
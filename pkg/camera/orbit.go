@@ -0,0 +1,55 @@
+package camera
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const maxOrbitPitch = math.Pi/2 - 0.01
+
+// Orbit circles a target point at a fixed distance, always looking at it.
+type Orbit struct {
+	Target      *mat.VecDense
+	Distance    float64 `ui:"1,64"`
+	MinDistance float64
+	MaxDistance float64
+	Yaw         float64
+	Pitch       float64
+	FOV         float32 `ui:"10,120"`
+}
+
+func NewOrbit(target *mat.VecDense, distance float64, fov float32) *Orbit {
+	return &Orbit{
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 1,
+		MaxDistance: 1024,
+		FOV:         fov,
+	}
+}
+
+func (o *Orbit) Rotate(yaw, pitch float64) {
+	o.Yaw += yaw
+	o.Pitch = math.Max(-maxOrbitPitch, math.Min(maxOrbitPitch, o.Pitch+pitch))
+}
+
+func (o *Orbit) Zoom(delta float64) {
+	o.Distance = math.Max(o.MinDistance, math.Min(o.MaxDistance, o.Distance+delta))
+}
+
+func (o *Orbit) Update(dt float64) *Pose {
+	offset := mat.NewVecDense(3, []float64{
+		math.Cos(o.Pitch) * math.Sin(o.Yaw),
+		math.Sin(o.Pitch),
+		-math.Cos(o.Pitch) * math.Cos(o.Yaw),
+	})
+
+	position := mat.NewVecDense(3, nil)
+	position.AddScaledVec(o.Target, o.Distance, offset)
+
+	direction := mat.NewVecDense(3, nil)
+	direction.ScaleVec(-1, offset)
+
+	return NewPose(position, direction, mat.NewVecDense(3, []float64{0, 1, 0}), o.FOV)
+}
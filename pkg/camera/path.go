@@ -0,0 +1,243 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"remnant/pkg/physics"
+	"remnant/pkg/program"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+const (
+	LinearInterpolation     = "linear"
+	CatmullRomInterpolation = "catmull-rom"
+	HermiteInterpolation    = "hermite"
+
+	SlerpRotation = "slerp"
+	SquadRotation = "squad"
+)
+
+// Keyframe is one recorded camera state. Orientation is stored as w, x, y, z.
+type Keyframe struct {
+	Time        float64    `json:"time"`
+	Position    [3]float64 `json:"position"`
+	Orientation [4]float64 `json:"orientation"`
+	FOV         float32    `json:"fov"`
+}
+
+func KeyframeFromCamera(t float64, c *program.Camera) Keyframe {
+	return Keyframe{
+		Time:        t,
+		Position:    [3]float64{c.Pos.AtVec(0), c.Pos.AtVec(1), c.Pos.AtVec(2)},
+		Orientation: [4]float64{c.Orientation.Real, c.Orientation.Imag, c.Orientation.Jmag, c.Orientation.Kmag},
+		FOV:         c.FOV,
+	}
+}
+
+func (k *Keyframe) position() *mat.VecDense {
+	return mat.NewVecDense(3, []float64{k.Position[0], k.Position[1], k.Position[2]})
+}
+
+func (k *Keyframe) orientation() quat.Number {
+	return quat.Number{Real: k.Orientation[0], Imag: k.Orientation[1], Jmag: k.Orientation[2], Kmag: k.Orientation[3]}
+}
+
+// Path is a keyframed flythrough. Position follows a spline chosen by
+// Interpolation, with Tension only used by hermite (0 is catmull-rom).
+// Orientation is interpolated with slerp or squad.
+type Path struct {
+	Interpolation string     `json:"interpolation"`
+	Tension       float64    `json:"tension"`
+	Rotation      string     `json:"rotation"`
+	Keyframes     []Keyframe `json:"keyframes"`
+}
+
+func NewPath() *Path {
+	return &Path{
+		Interpolation: CatmullRomInterpolation,
+		Rotation:      SquadRotation,
+	}
+}
+
+func LoadPath(file string) (*Path, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPath()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("camera path %s: %w", file, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("camera path %s: %w", file, err)
+	}
+
+	sort.SliceStable(p.Keyframes, func(i, j int) bool {
+		return p.Keyframes[i].Time < p.Keyframes[j].Time
+	})
+	p.alignHemispheres()
+	return p, nil
+}
+
+func (p *Path) Save(file string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+func (p *Path) Add(k Keyframe) {
+	p.Keyframes = append(p.Keyframes, k)
+	p.alignHemispheres()
+}
+
+func (p *Path) Duration() float64 {
+	if len(p.Keyframes) == 0 {
+		return 0
+	}
+	return p.Keyframes[len(p.Keyframes)-1].Time - p.Keyframes[0].Time
+}
+
+// Sample evaluates the path at time t, clamped to the first and last keyframe.
+func (p *Path) Sample(t float64) (*mat.VecDense, quat.Number, float32) {
+	n := len(p.Keyframes)
+	switch {
+	case n == 0:
+		return mat.NewVecDense(3, nil), quat.Number{Real: 1}, 60
+	case t <= p.Keyframes[0].Time || n == 1:
+		k := &p.Keyframes[0]
+		return k.position(), k.orientation(), k.FOV
+	case t >= p.Keyframes[n-1].Time:
+		k := &p.Keyframes[n-1]
+		return k.position(), k.orientation(), k.FOV
+	}
+
+	i := sort.Search(n, func(i int) bool { return p.Keyframes[i].Time > t }) - 1
+	k1, k2 := &p.Keyframes[i], &p.Keyframes[i+1]
+	h := k2.Time - k1.Time
+	u := 0.0
+	if h > 0 {
+		u = (t - k1.Time) / h
+	}
+
+	fov := k1.FOV + (k2.FOV-k1.FOV)*float32(u)
+	return p.samplePosition(i, u), p.sampleOrientation(i, u), fov
+}
+
+func (p *Path) samplePosition(i int, u float64) *mat.VecDense {
+	k1, k2 := &p.Keyframes[i], &p.Keyframes[i+1]
+	p1, p2 := k1.position(), k2.position()
+
+	if p.Interpolation == LinearInterpolation {
+		out := mat.NewVecDense(3, nil)
+		lerp(out, p1, p2, u)
+		return out
+	}
+
+	tension := 0.0
+	if p.Interpolation == HermiteInterpolation {
+		tension = p.Tension
+	}
+
+	h := k2.Time - k1.Time
+	m1 := p.tangent(i, tension)
+	m2 := p.tangent(i+1, tension)
+
+	u2, u3 := u*u, u*u*u
+	h00 := 2*u3 - 3*u2 + 1
+	h10 := u3 - 2*u2 + u
+	h01 := -2*u3 + 3*u2
+	h11 := u3 - u2
+
+	out := mat.NewVecDense(3, nil)
+	out.AddScaledVec(out, h00, p1)
+	out.AddScaledVec(out, h10*h, m1)
+	out.AddScaledVec(out, h01, p2)
+	out.AddScaledVec(out, h11*h, m2)
+	return out
+}
+
+// tangent is the cardinal spline tangent at keyframe i in units per second,
+// using one sided differences at the ends of the path.
+func (p *Path) tangent(i int, tension float64) *mat.VecDense {
+	prev, next := i-1, i+1
+	if prev < 0 {
+		prev = i
+	}
+	if next >= len(p.Keyframes) {
+		next = i
+	}
+
+	m := mat.NewVecDense(3, nil)
+	dt := p.Keyframes[next].Time - p.Keyframes[prev].Time
+	if dt <= 0 {
+		return m
+	}
+	m.SubVec(p.Keyframes[next].position(), p.Keyframes[prev].position())
+	m.ScaleVec((1-tension)/dt, m)
+	return m
+}
+
+func (p *Path) sampleOrientation(i int, u float64) quat.Number {
+	q1, q2 := p.Keyframes[i].orientation(), p.Keyframes[i+1].orientation()
+	if p.Rotation != SquadRotation {
+		return physics.Slerp(q1, q2, u)
+	}
+
+	s1 := p.squadControl(i)
+	s2 := p.squadControl(i + 1)
+	return physics.Slerp(physics.Slerp(q1, q2, u), physics.Slerp(s1, s2, u), 2*u*(1-u))
+}
+
+// squadControl is the inner control point s_i = q_i exp(-(log(q_i^-1 q_i+1) + log(q_i^-1 q_i-1)) / 4).
+func (p *Path) squadControl(i int) quat.Number {
+	q := p.Keyframes[i].orientation()
+	if i == 0 || i == len(p.Keyframes)-1 {
+		return q
+	}
+
+	inv := quat.Conj(q)
+	next := quat.Log(quat.Mul(inv, p.Keyframes[i+1].orientation()))
+	prev := quat.Log(quat.Mul(inv, p.Keyframes[i-1].orientation()))
+	return physics.NormalizeQuaternion(quat.Mul(q, quat.Exp(quat.Scale(-0.25, quat.Add(next, prev)))))
+}
+
+// alignHemispheres flips keyframe quaternions so that neighbours have a
+// positive dot product, otherwise squad takes the long way round.
+func (p *Path) alignHemispheres() {
+	for i := 1; i < len(p.Keyframes); i++ {
+		a, b := &p.Keyframes[i-1].Orientation, &p.Keyframes[i].Orientation
+		if a[0]*b[0]+a[1]*b[1]+a[2]*b[2]+a[3]*b[3] < 0 {
+			for j := range b {
+				b[j] = -b[j]
+			}
+		}
+	}
+}
+
+func (p *Path) validate() error {
+	switch p.Interpolation {
+	case LinearInterpolation, CatmullRomInterpolation, HermiteInterpolation:
+	default:
+		return fmt.Errorf("unknown interpolation %q", p.Interpolation)
+	}
+	switch p.Rotation {
+	case SlerpRotation, SquadRotation:
+	default:
+		return fmt.Errorf("unknown rotation %q", p.Rotation)
+	}
+	for i, k := range p.Keyframes {
+		o := k.orientation()
+		if quat.Abs(o) == 0 {
+			return fmt.Errorf("keyframe %d: zero orientation", i)
+		}
+		p.Keyframes[i].Orientation = [4]float64{o.Real / quat.Abs(o), o.Imag / quat.Abs(o), o.Jmag / quat.Abs(o), o.Kmag / quat.Abs(o)}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package camera
+
+import (
+	"math"
+	"remnant/pkg/physics"
+	"remnant/pkg/program"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// Recorder samples a live camera into a Path every Interval seconds.
+type Recorder struct {
+	Path     *Path
+	Interval float64
+
+	time    float64
+	elapsed float64
+}
+
+func NewRecorder(interval float64) *Recorder {
+	return &Recorder{
+		Path:     NewPath(),
+		Interval: interval,
+	}
+}
+
+func (r *Recorder) Record(c *program.Camera, dt float64) {
+	r.time += dt
+	r.elapsed += dt
+	if len(r.Path.Keyframes) > 0 && r.elapsed < r.Interval {
+		return
+	}
+
+	r.Path.Add(KeyframeFromCamera(r.time, c))
+	r.elapsed = 0
+}
+
+// Player plays a Path back. It is a Controller, so it can be registered with
+// a Rig or applied to a camera directly.
+type Player struct {
+	Path    *Path
+	Loop    bool
+	Time    float64
+	Playing bool
+
+	looped bool
+}
+
+func NewPlayer(path *Path, loop bool) *Player {
+	p := &Player{
+		Path:    path,
+		Loop:    loop,
+		Playing: true,
+	}
+	p.Rewind()
+	return p
+}
+
+func (p *Player) Rewind() {
+	p.Time = 0
+	if len(p.Path.Keyframes) > 0 {
+		p.Time = p.Path.Keyframes[0].Time
+	}
+}
+
+func (p *Player) Finished() bool {
+	n := len(p.Path.Keyframes)
+	return !p.Loop && n > 0 && p.Time >= p.Path.Keyframes[n-1].Time
+}
+
+func (p *Player) Update(dt float64) *Pose {
+	position, orientation, fov := p.advance(dt)
+	direction := physics.RotateVectorByQuaternion(mat.NewVecDense(3, []float64{0, 0, 1}), orientation)
+	up := physics.RotateVectorByQuaternion(mat.NewVecDense(3, []float64{0, 1, 0}), orientation)
+	return NewPose(position, direction, up, fov)
+}
+
+// Apply advances playback and moves c onto the path.
+func (p *Player) Apply(c *program.Camera, dt float64) {
+	position, orientation, fov := p.advance(dt)
+	c.Pos.CopyVec(position)
+	c.Orientation = orientation
+	c.FOV = fov
+}
+
+// Looped reports whether the last update jumped from the end of the path
+// back to the start.
+func (p *Player) Looped() bool {
+	return p.looped
+}
+
+func (p *Player) advance(dt float64) (*mat.VecDense, quat.Number, float32) {
+	p.looped = false
+	if p.Playing {
+		before := p.Time
+		p.Time += dt
+		if duration := p.Path.Duration(); p.Loop && duration > 0 {
+			start := p.Path.Keyframes[0].Time
+			p.Time = start + math.Mod(p.Time-start, duration)
+		}
+		p.looped = p.Time < before
+	}
+	return p.Path.Sample(p.Time)
+}
@@ -0,0 +1,94 @@
+package camera
+
+import (
+	"math"
+	"remnant/pkg/physics"
+	"remnant/pkg/program"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Pose is the view a controller wants the camera to take this frame.
+type Pose struct {
+	Position  *mat.VecDense
+	Direction *mat.VecDense
+	Up        *mat.VecDense
+	FOV       float32
+}
+
+func NewPose(position, direction, up *mat.VecDense, fov float32) *Pose {
+	p := &Pose{
+		Position:  mat.NewVecDense(3, nil),
+		Direction: mat.NewVecDense(3, nil),
+		Up:        mat.NewVecDense(3, nil),
+		FOV:       fov,
+	}
+	p.Position.CopyVec(position)
+	p.Direction.CopyVec(direction)
+	p.Up.CopyVec(up)
+	p.orthonormalize()
+	return p
+}
+
+func PoseFromCamera(c *program.Camera) *Pose {
+	return NewPose(c.Pos, c.Dir(), c.Up(), c.FOV)
+}
+
+func (p *Pose) Apply(c *program.Camera) {
+	c.Pos.CopyVec(p.Position)
+	c.SetBasis(p.Direction, p.Up)
+	c.FOV = p.FOV
+}
+
+// Blend interpolates from a to b. Directions are normalised-lerped and then
+// re-orthogonalised, which is close enough to a slerp for short blends.
+func Blend(a, b *Pose, t float64) *Pose {
+	t = math.Max(0, math.Min(1, t))
+
+	p := &Pose{
+		Position:  mat.NewVecDense(3, nil),
+		Direction: mat.NewVecDense(3, nil),
+		Up:        mat.NewVecDense(3, nil),
+		FOV:       a.FOV + (b.FOV-a.FOV)*float32(t),
+	}
+	lerp(p.Position, a.Position, b.Position, t)
+	lerp(p.Direction, a.Direction, b.Direction, t)
+	lerp(p.Up, a.Up, b.Up, t)
+
+	// opposite directions lerp through zero; fall back to the target
+	if p.Direction.Norm(2) < 1e-6 {
+		p.Direction.CopyVec(b.Direction)
+	}
+	p.orthonormalize()
+	return p
+}
+
+func (p *Pose) orthonormalize() {
+	normalize(p.Direction)
+	p.Up.AddScaledVec(p.Up, -mat.Dot(p.Up, p.Direction), p.Direction)
+	if p.Up.Norm(2) < 1e-6 {
+		// up is parallel to direction, pick any perpendicular axis
+		p.Up.CopyVec(physics.Cross(p.Direction, mat.NewVecDense(3, []float64{1, 0, 0})))
+		if p.Up.Norm(2) < 1e-6 {
+			p.Up.CopyVec(physics.Cross(p.Direction, mat.NewVecDense(3, []float64{0, 0, 1})))
+		}
+	}
+	normalize(p.Up)
+}
+
+func lerp(dst, a, b *mat.VecDense, t float64) {
+	d := mat.NewVecDense(3, nil)
+	d.SubVec(b, a)
+	dst.AddScaledVec(a, t, d)
+}
+
+func normalize(v *mat.VecDense) {
+	if n := v.Norm(2); n > 0 {
+		v.ScaleVec(1/n, v)
+	}
+}
+
+func smoothstep(t float64) float64 {
+	t = math.Max(0, math.Min(1, t))
+	return t * t * (3 - 2*t)
+}
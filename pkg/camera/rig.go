@@ -0,0 +1,147 @@
+package camera
+
+import (
+	"remnant/pkg/program"
+)
+
+type Mode int
+
+const (
+	FreeFlyMode Mode = iota
+	OrbitMode
+	ChaseMode
+	CockpitMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case FreeFlyMode:
+		return "free-fly"
+	case OrbitMode:
+		return "orbit"
+	case ChaseMode:
+		return "chase"
+	case CockpitMode:
+		return "cockpit"
+	}
+	return "unknown"
+}
+
+// Controller produces the desired camera pose each frame.
+type Controller interface {
+	Update(dt float64) *Pose
+}
+
+// Rig drives a program.Camera from one of several registered controllers and
+// blends smoothly whenever the active mode changes.
+type Rig struct {
+	Camera    *program.Camera
+	BlendTime float64
+
+	controllers map[Mode]Controller
+	order       []Mode
+	mode        Mode
+	from        *Pose
+	elapsed     float64
+}
+
+func NewRig(camera *program.Camera, blendTime float64) *Rig {
+	return &Rig{
+		Camera:      camera,
+		BlendTime:   blendTime,
+		controllers: make(map[Mode]Controller),
+	}
+}
+
+// Register adds a controller for mode. The first controller registered
+// becomes the active one.
+func (r *Rig) Register(mode Mode, c Controller) {
+	if _, ok := r.controllers[mode]; !ok {
+		r.order = append(r.order, mode)
+	}
+	r.controllers[mode] = c
+
+	if len(r.order) == 1 {
+		r.mode = mode
+	}
+}
+
+func (r *Rig) Mode() Mode {
+	return r.mode
+}
+
+func (r *Rig) Controller() Controller {
+	return r.controllers[r.mode]
+}
+
+func (r *Rig) IsBlending() bool {
+	return r.from != nil
+}
+
+// SetMode switches to mode, blending from wherever the camera currently is.
+// Unregistered modes are ignored.
+func (r *Rig) SetMode(mode Mode) {
+	if _, ok := r.controllers[mode]; !ok || mode == r.mode {
+		return
+	}
+
+	r.mode = mode
+	r.from = PoseFromCamera(r.Camera)
+	r.elapsed = 0
+}
+
+// SetFOV changes the field of view of every registered controller.
+func (r *Rig) SetFOV(fov float32) {
+	for _, c := range r.controllers {
+		switch c := c.(type) {
+		case *Chase:
+			c.FOV = fov
+		case *Cockpit:
+			c.FOV = fov
+		case *FreeFly:
+			c.FOV = fov
+		case *Orbit:
+			c.FOV = fov
+		}
+	}
+}
+
+// Snap ends any blend and puts spring driven controllers at rest, for when
+// what they follow jumps.
+func (r *Rig) Snap() {
+	r.from = nil
+	for _, c := range r.controllers {
+		if chase, ok := c.(*Chase); ok {
+			chase.Snap()
+		}
+	}
+}
+
+// Cycle switches to the next registered mode.
+func (r *Rig) Cycle() {
+	for i, m := range r.order {
+		if m == r.mode {
+			r.SetMode(r.order[(i+1)%len(r.order)])
+			return
+		}
+	}
+}
+
+func (r *Rig) Update(dt float64) {
+	c, ok := r.controllers[r.mode]
+	if !ok {
+		return
+	}
+
+	pose := c.Update(dt)
+	if r.from != nil {
+		r.elapsed += dt
+		if r.BlendTime > 0 && r.elapsed < r.BlendTime {
+			pose = Blend(r.from, pose, smoothstep(r.elapsed/r.BlendTime))
+		} else {
+			r.from = nil
+		}
+	}
+
+	pose.Apply(r.Camera)
+}
@@ -0,0 +1,275 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"remnant/pkg/program"
+	"sync"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"gonum.org/v1/gonum/mat"
+)
+
+// metadataKeyword is the keyword of the PNG tEXt chunk holding Metadata.
+const metadataKeyword = "remnant"
+
+// Metadata describes how a frame was rendered, enough to put the camera back
+// where it was.
+type Metadata struct {
+	Scene  string     `json:"scene"`
+	Seed   int64      `json:"seed"`
+	Time   float64    `json:"time"`
+	Taken  time.Time  `json:"taken"`
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	Camera CameraMeta `json:"camera"`
+	Light  [3]float64 `json:"light"`
+	// Debug is the debug view the frame shows, empty for the shaded image
+	Debug string `json:"debug,omitempty"`
+}
+
+type CameraMeta struct {
+	Position  [3]float64 `json:"position"`
+	Direction [3]float64 `json:"direction"`
+	Up        [3]float64 `json:"up"`
+	FOV       float32    `json:"fov"`
+}
+
+func NewCameraMeta(c *program.Camera) CameraMeta {
+	return CameraMeta{
+		Position:  array(c.Pos),
+		Direction: array(c.Dir()),
+		Up:        array(c.Up()),
+		FOV:       c.FOV,
+	}
+}
+
+// Apply moves c to the recorded view.
+func (m CameraMeta) Apply(c *program.Camera) {
+	c.Pos.CopyVec(mat.NewVecDense(3, m.Position[:]))
+	c.SetBasis(mat.NewVecDense(3, m.Direction[:]), mat.NewVecDense(3, m.Up[:]))
+	c.FOV = m.FOV
+}
+
+// ApplyLight moves l to the recorded light position.
+func (m *Metadata) ApplyLight(l *program.Light) {
+	l.Position.CopyVec(mat.NewVecDense(3, m.Light[:]))
+}
+
+func array(v *mat.VecDense) [3]float64 {
+	return [3]float64{v.AtVec(0), v.AtVec(1), v.AtVec(2)}
+}
+
+// ReadPixels reads the bottom-left width by height pixels of the bound read
+// framebuffer, flipped so the first row is the top of the screen.
+func ReadPixels(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	stride := img.Stride
+	row := make([]byte, stride)
+	for y := 0; y < height/2; y++ {
+		top := img.Pix[y*stride : (y+1)*stride]
+		bottom := img.Pix[(height-1-y)*stride : (height-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+
+	opaque(img)
+	return img
+}
+
+// opaque sets alpha to 1. The alpha channel holds whatever the last pass
+// wrote, not coverage.
+func opaque(img *image.RGBA) {
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+}
+
+// EncodePNG writes img as a PNG with meta stored as JSON in a tEXt chunk.
+func EncodePNG(w io.Writer, img image.Image, meta *Metadata) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	text, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	// the signature and IHDR come first, ancillary chunks may follow
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	data := buf.Bytes()
+	if _, err := w.Write(data[:ihdrEnd]); err != nil {
+		return err
+	}
+	if err := writeChunk(w, "tEXt", append([]byte(metadataKeyword+"\x00"), text...)); err != nil {
+		return err
+	}
+	_, err = w.Write(data[ihdrEnd:])
+	return err
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadMetadata returns the metadata stored in a screenshot.
+func ReadMetadata(file string) (*Metadata, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	var signature [8]byte
+	if _, err := io.ReadFull(r, signature[:]); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	remaining := info.Size() - int64(len(signature))
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("%s: no %s metadata", file, metadataKeyword)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		kind := string(header[4:])
+
+		// the length is read from the file, check it before allocating
+		remaining -= int64(len(header))
+		if length > math.MaxInt32 || int64(length)+4 > remaining {
+			return nil, fmt.Errorf("%s: bad %q chunk length %d", file, kind, length)
+		}
+		remaining -= int64(length) + 4
+
+		data := make([]byte, length+4) // with the crc
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		data = data[:length]
+
+		keyword := []byte(metadataKeyword + "\x00")
+		if kind == "tEXt" && bytes.HasPrefix(data, keyword) {
+			meta := &Metadata{}
+			if err := json.Unmarshal(data[len(keyword):], meta); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			return meta, nil
+		}
+		if kind == "IEND" {
+			return nil, fmt.Errorf("%s: no %s metadata", file, metadataKeyword)
+		}
+	}
+}
+
+// Screenshots saves frames to Dir. Pixels are read on the calling (GL)
+// thread, encoding and writing happen on a goroutine.
+type Screenshots struct {
+	Dir string
+
+	wg    sync.WaitGroup
+	count int
+}
+
+func NewScreenshots(dir string) *Screenshots {
+	return &Screenshots{Dir: dir}
+}
+
+// Take captures the window framebuffer. The file name is logged once it has
+// been written.
+func (s *Screenshots) Take(width, height int, meta *Metadata) {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	img := ReadPixels(width, height)
+
+	s.save(width, height, meta, "", func() image.Image { return img })
+}
+
+// Render saves the image render returns, calling it on the goroutine. It is
+// for frames that don't come from the framebuffer, like the CPU reference.
+func (s *Screenshots) Render(width, height int, meta *Metadata, render func() image.Image) {
+	s.save(width, height, meta, "-ref", render)
+}
+
+func (s *Screenshots) save(width, height int, meta *Metadata, suffix string, render func() image.Image) {
+	meta.Width, meta.Height = width, height
+	meta.Taken = time.Now()
+
+	s.count++
+	file := filepath.Join(s.Dir, fmt.Sprintf("remnant-%s-%03d%s.png", meta.Taken.Format("20060102-150405"), s.count, suffix))
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := save(file, render(), meta); err != nil {
+			log.Println("screenshot:", err)
+			return
+		}
+		log.Println("saved screenshot", file)
+	}()
+}
+
+func save(file string, img image.Image, meta *Metadata) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = EncodePNG(w, img, meta)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Wait blocks until every screenshot taken so far has been written.
+func (s *Screenshots) Wait() {
+	s.wg.Wait()
+}
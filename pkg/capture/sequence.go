@@ -0,0 +1,285 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// SequenceFormat is how a Sequence stores frames.
+type SequenceFormat string
+
+const (
+	// PNGSequence writes frame_000000.png, frame_000001.png, ...
+	PNGSequence SequenceFormat = "png"
+	// Y4MSequence writes a single uncompressed capture.y4m stream that
+	// ffmpeg and most editors read directly.
+	Y4MSequence SequenceFormat = "y4m"
+)
+
+// FrameMeta is the manifest entry for one captured frame.
+type FrameMeta struct {
+	Index  int        `json:"index"`
+	File   string     `json:"file,omitempty"`
+	Time   float64    `json:"time"`
+	Camera CameraMeta `json:"camera"`
+}
+
+// Manifest is written next to the frames when a Sequence is closed.
+type Manifest struct {
+	FPS    int            `json:"fps"`
+	Width  int            `json:"width"`
+	Height int            `json:"height"`
+	Format SequenceFormat `json:"format"`
+	Frames []FrameMeta    `json:"frames"`
+}
+
+// Sequence captures every frame at a fixed frame rate. Pixels are read back
+// through two pixel buffer objects so the GPU copy of one frame overlaps the
+// next, and encoding happens on a pool of workers. Capture only blocks when
+// the workers fall more than a few frames behind.
+type Sequence struct {
+	Dir    string
+	FPS    int
+	Format SequenceFormat
+
+	manifest Manifest
+	jobs     chan frameJob
+	free     chan *image.RGBA
+	workers  sync.WaitGroup
+	y4m      *y4mWriter
+
+	pbo      [2]uint32
+	pboSize  [2]int
+	next     int
+	pending  *pendingRead
+	captured int
+
+	mu  sync.Mutex
+	err error
+}
+
+type frameJob struct {
+	meta FrameMeta
+	img  *image.RGBA
+}
+
+type pendingRead struct {
+	pbo    int
+	camera CameraMeta
+	w, h   int
+}
+
+func NewSequence(dir string, fps int, format SequenceFormat) (*Sequence, error) {
+	if format != PNGSequence && format != Y4MSequence {
+		return nil, fmt.Errorf("unknown capture format %q", format)
+	}
+	if fps <= 0 {
+		return nil, fmt.Errorf("capture frame rate must be positive, got %d", fps)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	workers := runtime.NumCPU()
+	s := &Sequence{
+		Dir:      dir,
+		FPS:      fps,
+		Format:   format,
+		manifest: Manifest{FPS: fps, Format: format},
+		jobs:     make(chan frameJob, 2*workers),
+		free:     make(chan *image.RGBA, 2*workers+2),
+	}
+
+	if format == Y4MSequence {
+		y4m, err := newY4MWriter(filepath.Join(dir, "capture.y4m"), fps)
+		if err != nil {
+			return nil, err
+		}
+		s.y4m = y4m
+	}
+
+	gl.GenBuffers(2, &s.pbo[0])
+	for i := 0; i < workers; i++ {
+		s.workers.Add(1)
+		go s.work()
+	}
+	return s, nil
+}
+
+// Frames is how many frames have been captured so far.
+func (s *Sequence) Frames() int {
+	return s.captured
+}
+
+// FrameTime is the fixed timestep the game should advance by per frame.
+func (s *Sequence) FrameTime() float64 {
+	return 1 / float64(s.FPS)
+}
+
+// Capture starts reading back the window framebuffer and hands the previous
+// frame, whose read has finished by now, to the workers.
+func (s *Sequence) Capture(width, height int, camera CameraMeta) {
+	if s.manifest.Width == 0 {
+		s.manifest.Width, s.manifest.Height = width, height
+	}
+	if s.y4m != nil && (width != s.manifest.Width || height != s.manifest.Height) {
+		// a y4m stream has a single frame size
+		s.fail(fmt.Errorf("window resized to %dx%d during y4m capture, frame dropped", width, height))
+		return
+	}
+
+	s.captured++
+
+	size := width * height * 4
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, s.pbo[s.next])
+	if s.pboSize[s.next] != size {
+		gl.BufferData(gl.PIXEL_PACK_BUFFER, size, nil, gl.STREAM_READ)
+		s.pboSize[s.next] = size
+	}
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+
+	if s.pending != nil {
+		s.collect(s.pending)
+	}
+	s.pending = &pendingRead{pbo: s.next, camera: camera, w: width, h: height}
+	s.next = 1 - s.next
+}
+
+// collect copies a finished read out of its buffer, flipped upright, and
+// adds it to the manifest. Frames are numbered as they are collected so a
+// lost one leaves no gap in the stream.
+func (s *Sequence) collect(r *pendingRead) {
+	index := len(s.manifest.Frames)
+
+	size := r.w * r.h * 4
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, s.pbo[r.pbo])
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, size, gl.MAP_READ_BIT)
+	if ptr == nil {
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+		s.fail(fmt.Errorf("could not map pixel buffer for frame %d, frame dropped", index))
+		return
+	}
+
+	pixels := unsafe.Slice((*byte)(ptr), size)
+	img := s.image(r.w, r.h)
+	stride := r.w * 4
+	for y := 0; y < r.h; y++ {
+		copy(img.Pix[y*stride:(y+1)*stride], pixels[(r.h-1-y)*stride:(r.h-y)*stride])
+	}
+	opaque(img)
+
+	gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+
+	meta := FrameMeta{
+		Index:  index,
+		Time:   float64(index) / float64(s.FPS),
+		Camera: r.camera,
+	}
+	if s.Format == PNGSequence {
+		meta.File = fmt.Sprintf("frame_%06d.png", meta.Index)
+	}
+	s.manifest.Frames = append(s.manifest.Frames, meta)
+	s.jobs <- frameJob{meta: meta, img: img}
+}
+
+// image reuses a buffer the workers are done with when the size matches.
+func (s *Sequence) image(w, h int) *image.RGBA {
+	select {
+	case img := <-s.free:
+		if img.Rect.Dx() == w && img.Rect.Dy() == h {
+			return img
+		}
+	default:
+	}
+	return image.NewRGBA(image.Rect(0, 0, w, h))
+}
+
+func (s *Sequence) work() {
+	defer s.workers.Done()
+	for job := range s.jobs {
+		var err error
+		if s.y4m != nil {
+			s.y4m.write(job.meta.Index, toYUV420(job.img))
+		} else {
+			err = saveFrame(filepath.Join(s.Dir, job.meta.File), job.img)
+		}
+		if err != nil {
+			s.fail(err)
+		}
+
+		select {
+		case s.free <- job.img:
+		default:
+		}
+	}
+}
+
+func saveFrame(file string, img image.Image) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = pngEncoder.Encode(w, img)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *Sequence) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	log.Println("capture:", err)
+}
+
+// Close flushes the frames still in flight, writes the manifest and
+// returns the first error hit while capturing.
+func (s *Sequence) Close() error {
+	if s.pending != nil {
+		s.collect(s.pending)
+		s.pending = nil
+	}
+	close(s.jobs)
+	s.workers.Wait()
+	gl.DeleteBuffers(2, &s.pbo[0])
+
+	if s.y4m != nil {
+		if err := s.y4m.close(); err != nil {
+			s.fail(err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(s.Dir, "manifest.json"), data, 0644)
+	}
+	if err != nil {
+		s.fail(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
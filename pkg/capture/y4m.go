@@ -0,0 +1,141 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sync"
+)
+
+// pngEncoder trades a little file size for speed, frames are usually
+// re-encoded anyway.
+var pngEncoder = png.Encoder{CompressionLevel: png.BestSpeed}
+
+// y4mWriter writes 4:2:0 frames to a YUV4MPEG2 stream. Frames arrive from
+// several workers and are put back in order before writing.
+type y4mWriter struct {
+	file *os.File
+	w    *bufio.Writer
+
+	mu      sync.Mutex
+	next    int
+	waiting map[int][]byte
+	header  bool
+	fps     int
+	err     error
+}
+
+func newY4MWriter(file string, fps int) (*y4mWriter, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	return &y4mWriter{
+		file:    f,
+		w:       bufio.NewWriterSize(f, 1<<20),
+		waiting: map[int][]byte{},
+		fps:     fps,
+	}, nil
+}
+
+// write queues frame index and writes every frame that is now in order.
+func (y *y4mWriter) write(index int, frame yuvFrame) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if !y.header {
+		// full range BT.601, chroma sited like JPEG
+		fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C420jpeg XCOLORRANGE=FULL\n", frame.w, frame.h, y.fps)
+		y.header = true
+	}
+
+	y.waiting[index] = frame.data
+	for {
+		data, ok := y.waiting[y.next]
+		if !ok {
+			return
+		}
+		delete(y.waiting, y.next)
+		y.next++
+
+		if _, err := y.w.WriteString("FRAME\n"); err != nil && y.err == nil {
+			y.err = err
+		}
+		if _, err := y.w.Write(data); err != nil && y.err == nil {
+			y.err = err
+		}
+	}
+}
+
+func (y *y4mWriter) close() error {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if len(y.waiting) > 0 && y.err == nil {
+		y.err = fmt.Errorf("y4m: %d frames missing from the stream", len(y.waiting))
+	}
+	if err := y.w.Flush(); err != nil && y.err == nil {
+		y.err = err
+	}
+	if err := y.file.Close(); err != nil && y.err == nil {
+		y.err = err
+	}
+	return y.err
+}
+
+type yuvFrame struct {
+	w, h int
+	data []byte
+}
+
+// toYUV420 converts to planar Y, Cb, Cr with chroma averaged over 2x2
+// blocks.
+func toYUV420(img *image.RGBA) yuvFrame {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	cw, ch := (w+1)/2, (h+1)/2
+
+	data := make([]byte, w*h+2*cw*ch)
+	yPlane := data[:w*h]
+	cbPlane := data[w*h : w*h+cw*ch]
+	crPlane := data[w*h+cw*ch:]
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*img.Stride + x*4
+			r, g, b := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])
+			yPlane[y*w+x] = clampByte(0.299*r + 0.587*g + 0.114*b)
+		}
+	}
+
+	for cy := 0; cy < ch; cy++ {
+		for cx := 0; cx < cw; cx++ {
+			var r, g, b, n float64
+			for y := 2 * cy; y < 2*cy+2 && y < h; y++ {
+				for x := 2 * cx; x < 2*cx+2 && x < w; x++ {
+					i := y*img.Stride + x*4
+					r += float64(img.Pix[i])
+					g += float64(img.Pix[i+1])
+					b += float64(img.Pix[i+2])
+					n++
+				}
+			}
+			r, g, b = r/n, g/n, b/n
+			cbPlane[cy*cw+cx] = clampByte(128 - 0.168736*r - 0.331264*g + 0.5*b)
+			crPlane[cy*cw+cx] = clampByte(128 + 0.5*r - 0.418688*g - 0.081312*b)
+		}
+	}
+
+	return yuvFrame{w: w, h: h, data: data}
+}
+
+func clampByte(v float64) byte {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return byte(v + 0.5)
+}
@@ -0,0 +1,51 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadConfig reads "name value" lines from file, as written by SaveConfig.
+// Blank lines and lines starting with # are skipped. Variables that are not
+// registered yet are set by ApplyPending. A missing file is not an error.
+func (c *Console) LoadConfig(file string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, " ")
+		if err := c.Set(name, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s:%d: %v", file, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// SaveConfig writes the archived variables that differ from their defaults
+// to file. Leaving defaults out lets them follow the code, and presets
+// picked on the command line, instead of being pinned by an old config.
+func (c *Console) SaveConfig(file string) error {
+	var b strings.Builder
+	b.WriteString("# written on exit, edit while the game isn't running\n")
+	for _, name := range c.varNames() {
+		v := c.vars[name]
+		if v.Archive && v.String() != v.Default() {
+			fmt.Fprintf(&b, "%s %s\n", name, v)
+		}
+	}
+	return os.WriteFile(file, []byte(b.String()), 0644)
+}
@@ -0,0 +1,233 @@
+package console
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// maxLines is how much output the console keeps.
+const maxLines = 200
+
+// Command runs with the words typed after its name.
+type Command struct {
+	Name string
+	Help string
+	Run  func(args []string) error
+}
+
+// Console is a registry of commands and variables with a line editor and
+// scrollback. Open it with the grave key, see HandleEvent.
+type Console struct {
+	Open bool
+
+	commands map[string]*Command
+	vars     map[string]*Var
+	// pending holds values from the config file and the command line for
+	// variables that are not registered yet, applied by ApplyPending.
+	pending map[string]string
+
+	lines   []string
+	input   string
+	history []string
+	recall  int
+	scroll  int
+	view    *view
+}
+
+func New() *Console {
+	c := &Console{
+		commands: map[string]*Command{},
+		vars:     map[string]*Var{},
+		pending:  map[string]string{},
+	}
+
+	c.Register("help", "list commands, or show help for one", c.help)
+	c.Register("cvars", "list variables and their values", c.listVars)
+	c.Register("clear", "clear the console", func([]string) error {
+		c.lines = nil
+		return nil
+	})
+	return c
+}
+
+func (c *Console) Register(name, help string, run func(args []string) error) {
+	c.commands[name] = &Command{Name: name, Help: help, Run: run}
+}
+
+func (c *Console) FloatVar(name string, ptr *float64, help string) *Var {
+	return c.addVar(newVar(name, ptr, help))
+}
+
+func (c *Console) IntVar(name string, ptr *int, help string) *Var {
+	return c.addVar(newVar(name, ptr, help))
+}
+
+func (c *Console) BoolVar(name string, ptr *bool, help string) *Var {
+	return c.addVar(newVar(name, ptr, help))
+}
+
+func (c *Console) StringVar(name string, ptr *string, help string) *Var {
+	return c.addVar(newVar(name, ptr, help))
+}
+
+func (c *Console) addVar(v *Var) *Var {
+	c.vars[v.Name] = v
+	return v
+}
+
+func (c *Console) Var(name string) (*Var, bool) {
+	v, ok := c.vars[name]
+	return v, ok
+}
+
+// Set sets a variable by name. Values for variables that are not registered
+// yet are kept until ApplyPending.
+func (c *Console) Set(name, value string) error {
+	v, ok := c.vars[name]
+	if !ok {
+		c.pending[name] = value
+		return nil
+	}
+	return v.Set(value)
+}
+
+// ApplyPending sets the variables given values before they were
+// registered, running their OnChange. Values for names that are still
+// unknown are reported and dropped.
+func (c *Console) ApplyPending() {
+	names := make([]string, 0, len(c.pending))
+	for name := range c.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, ok := c.vars[name]
+		if !ok {
+			c.Printf("unknown variable %q", name)
+			continue
+		}
+		if err := v.Set(c.pending[name]); err != nil {
+			c.Printf("%v", err)
+		}
+	}
+	c.pending = map[string]string{}
+}
+
+// Printf adds a line to the console and the log.
+func (c *Console) Printf(format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...)
+	log.Println(line)
+	c.lines = append(c.lines, strings.Split(line, "\n")...)
+	if len(c.lines) > maxLines {
+		c.lines = c.lines[len(c.lines)-maxLines:]
+	}
+}
+
+// Execute runs one line: a command and its arguments, a variable name to
+// print it, or a variable name and a value to set it.
+func (c *Console) Execute(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	name, args := fields[0], fields[1:]
+
+	if cmd, ok := c.commands[name]; ok {
+		if err := cmd.Run(args); err != nil {
+			c.Printf("%s: %v", name, err)
+		}
+		return
+	}
+
+	if v, ok := c.vars[name]; ok {
+		if len(args) == 0 {
+			c.Printf("%s = %s (%s, default %s) %s", v.Name, v, v.Type(), v.Default(), v.Help)
+			return
+		}
+		if err := v.Set(strings.Join(args, " ")); err != nil {
+			c.Printf("%v", err)
+		}
+		return
+	}
+
+	c.Printf("unknown command %q, try help", name)
+}
+
+func (c *Console) help(args []string) error {
+	if len(args) > 0 {
+		if cmd, ok := c.commands[args[0]]; ok {
+			c.Printf("%s: %s", cmd.Name, cmd.Help)
+			return nil
+		}
+		if v, ok := c.vars[args[0]]; ok {
+			c.Printf("%s (%s): %s", v.Name, v.Type(), v.Help)
+			return nil
+		}
+		return fmt.Errorf("no command or variable %q", args[0])
+	}
+
+	for _, name := range c.commandNames() {
+		c.Printf("%-10s %s", name, c.commands[name].Help)
+	}
+	return nil
+}
+
+func (c *Console) listVars([]string) error {
+	for _, name := range c.varNames() {
+		v := c.vars[name]
+		c.Printf("%-10s %-8s %s", name, v, v.Help)
+	}
+	return nil
+}
+
+// complete extends the input to the longest prefix shared by the commands
+// and variables it could name, listing them when there is more than one.
+func (c *Console) complete() {
+	if strings.Contains(c.input, " ") {
+		return
+	}
+
+	var matches []string
+	for _, name := range append(c.commandNames(), c.varNames()...) {
+		if strings.HasPrefix(name, c.input) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(matches) == 1 {
+		prefix += " "
+	} else if prefix == c.input {
+		c.Printf("%s", strings.Join(matches, "  "))
+	}
+	c.input = prefix
+}
+
+func (c *Console) commandNames() []string {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Console) varNames() []string {
+	names := make([]string, 0, len(c.vars))
+	for name := range c.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
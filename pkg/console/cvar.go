@@ -0,0 +1,91 @@
+package console
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Var is a console variable bound to a Go value. Typing its name prints it,
+// its name and a value sets it.
+type Var struct {
+	Name string
+	Help string
+	// Archive saves the variable to the config file when it differs from
+	// the value it was registered with.
+	Archive bool
+	// OnChange runs after the value has been set from the console, the
+	// config file or the command line.
+	OnChange func()
+
+	ptr interface{}
+	def string
+}
+
+func newVar(name string, ptr interface{}, help string) *Var {
+	v := &Var{Name: name, Help: help, ptr: ptr}
+	v.def = v.String()
+	return v
+}
+
+// Set parses value as the variable's type and stores it.
+func (v *Var) Set(value string) error {
+	var err error
+	switch p := v.ptr.(type) {
+	case *float64:
+		var f float64
+		if f, err = strconv.ParseFloat(value, 64); err == nil {
+			*p = f
+		}
+	case *int:
+		var n int
+		if n, err = strconv.Atoi(value); err == nil {
+			*p = n
+		}
+	case *bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			*p = b
+		}
+	case *string:
+		*p = value
+	}
+	if err != nil {
+		return fmt.Errorf("%s takes %s values: %v", v.Name, v.Type(), err)
+	}
+
+	if v.OnChange != nil {
+		v.OnChange()
+	}
+	return nil
+}
+
+func (v *Var) String() string {
+	switch p := v.ptr.(type) {
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *string:
+		return *p
+	}
+	return ""
+}
+
+func (v *Var) Type() string {
+	switch v.ptr.(type) {
+	case *float64:
+		return "float"
+	case *int:
+		return "int"
+	case *bool:
+		return "bool"
+	}
+	return "string"
+}
+
+// Default is the value the variable was registered with.
+func (v *Var) Default() string {
+	return v.def
+}
@@ -0,0 +1,157 @@
+package console
+
+import (
+	"unicode/utf8"
+
+	glprogram "remnant/pkg/gl"
+	"remnant/pkg/input"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+var (
+	background = [4]float32{0.05, 0.05, 0.07, 0.9}
+	inputColor = [4]float32{1, 0.85, 0.4, 1}
+	textColor  = [4]float32{0.85, 0.85, 0.85, 1}
+)
+
+const (
+	// consoleHeight is the share of the window the open console covers.
+	consoleHeight = 0.4
+	padding       = 4
+)
+
+type view struct {
+	text *glprogram.Text
+}
+
+// Init creates what the console needs to draw. A console without it still
+// runs commands and variables, it just can't be opened.
+func (c *Console) Init(pp *glprogram.Preprocessor) error {
+	text, err := glprogram.NewText(pp)
+	if err != nil {
+		return err
+	}
+	text.Shadow = false
+	c.view = &view{text: text}
+	return nil
+}
+
+// HandleEvent opens the console on the grave key and, while it is open,
+// edits the input line. It reports whether the console used the event; an
+// open console takes every key and character.
+func (c *Console) HandleEvent(e input.Event) bool {
+	if c.view == nil {
+		return false
+	}
+
+	if !c.Open {
+		if e.Kind == input.KeyEvent && e.Key == glfw.KeyGraveAccent && e.Action == glfw.Press {
+			c.Open = true
+			return true
+		}
+		return false
+	}
+
+	switch e.Kind {
+	case input.CharEvent:
+		// the key that closes the console also types a character
+		if e.Char != '`' && e.Char != '~' {
+			c.input += string(e.Char)
+		}
+		return true
+	case input.KeyEvent:
+		if e.Action == glfw.Press || e.Action == glfw.Repeat {
+			c.key(e.Key)
+		}
+		return true
+	}
+	return false
+}
+
+func (c *Console) key(key glfw.Key) {
+	switch key {
+	case glfw.KeyGraveAccent, glfw.KeyEscape:
+		c.Open = false
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		c.Printf("> %s", c.input)
+		if c.input != "" {
+			c.history = append(c.history, c.input)
+		}
+		c.recall = len(c.history)
+		c.scroll = 0
+		line := c.input
+		c.input = ""
+		c.Execute(line)
+	case glfw.KeyBackspace:
+		_, size := utf8.DecodeLastRuneInString(c.input)
+		c.input = c.input[:len(c.input)-size]
+	case glfw.KeyUp:
+		if c.recall > 0 {
+			c.recall--
+			c.input = c.history[c.recall]
+		}
+	case glfw.KeyDown:
+		if c.recall < len(c.history)-1 {
+			c.recall++
+			c.input = c.history[c.recall]
+		} else {
+			c.recall = len(c.history)
+			c.input = ""
+		}
+	case glfw.KeyTab:
+		c.complete()
+	case glfw.KeyPageUp:
+		c.scroll += 5
+		if c.scroll > len(c.lines)-1 {
+			c.scroll = len(c.lines) - 1
+		}
+	case glfw.KeyPageDown:
+		c.scroll -= 5
+		if c.scroll < 0 {
+			c.scroll = 0
+		}
+	}
+}
+
+// Draw draws the open console over the top of a window framebuffer of width
+// by height into the bound framebuffer.
+func (c *Console) Draw(width, height int, pixelRatio float64) {
+	if c.view == nil || !c.Open {
+		return
+	}
+
+	text := c.view.text
+	scale := float32(pixelRatio)
+	pad := padding * scale
+	lineHeight := glprogram.GlyphHeight * scale
+	bottom := float32(height) * consoleHeight
+
+	text.Rect(0, 0, float32(width), bottom, background)
+
+	y := bottom - pad - lineHeight
+	text.Draw(pad, y, scale, inputColor, "> "+c.input+"_")
+
+	for i := len(c.lines) - 1 - c.scroll; i >= 0; i-- {
+		y -= lineHeight
+		if y < 0 {
+			break
+		}
+		text.Draw(pad, y, scale, textColor, c.lines[i])
+	}
+
+	text.Flush(width, height)
+}
+
+func (c *Console) Stage(r *glprogram.Reload) error {
+	if c.view == nil {
+		return nil
+	}
+	return c.view.text.Stage(r)
+}
+
+func (c *Console) Delete() {
+	if c.view != nil {
+		c.view.text.Delete()
+	}
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"remnant/pkg/scene"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// registerConsole adds the game's commands and variables to the console.
+// Values loaded from the config file and the command line are applied once
+// everything is registered.
+func (g *Game) registerConsole() {
+	c := g.Console
+	p := g.program
+
+	c.Register("teleport", "teleport x y z: move the player to a position", func(args []string) error {
+		s, ok := g.Scenes.Current().(scene.Controllable)
+		if !ok {
+			return fmt.Errorf("not supported by this scene")
+		}
+		if len(args) != 3 {
+			return fmt.Errorf("want x y z")
+		}
+		pos := mat.NewVecDense(3, nil)
+		for i, arg := range args {
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return err
+			}
+			pos.SetVec(i, f)
+		}
+		s.Teleport(pos)
+		return nil
+	})
+
+	c.Register("scene", "scene list | scene load name: switch scenes", func(args []string) error {
+		if len(args) == 2 && args[0] == "load" {
+			return g.Scenes.LoadNamed(args[1])
+		}
+		if len(args) == 1 && args[0] == "list" {
+			c.Printf("%s", strings.Join(g.Scenes.Names(), "  "))
+			return nil
+		}
+		return fmt.Errorf("want list or load name")
+	})
+
+	c.Register("noclip", "fly through everything, again to land", func(args []string) error {
+		s, ok := g.Scenes.Current().(scene.Controllable)
+		if !ok {
+			return fmt.Errorf("not supported by this scene")
+		}
+		g.noclip = !g.noclip
+		s.SetNoclip(g.noclip)
+		c.Printf("noclip %v", g.noclip)
+		return nil
+	})
+
+	fov := c.FloatVar("fov", &g.fov, "field of view in degrees, 0 keeps the scene's own")
+	fov.Archive = true
+	fov.OnChange = g.applyFOV
+
+	steps := c.IntVar("r_steps", &g.steps, "ray march steps, 0 keeps the preset's")
+	steps.Archive = true
+	steps.OnChange = func() {
+		if g.steps <= 0 || g.steps == p.Preset.MaxSteps {
+			return
+		}
+		preset := p.Preset
+		preset.Name, preset.MaxSteps, preset.Scale = "custom", g.steps, p.Scaler.Scale
+		g.reloadErr = p.ApplyPreset(preset)
+		if g.reloadErr != nil {
+			log.Println(g.reloadErr)
+		}
+	}
+
+	c.FloatVar("timescale", &g.TimeScale, "speed of simulated time")
+
+	taa := c.BoolVar("r_taa", &p.Temporal.Enabled, "temporal anti-aliasing")
+	taa.Archive = true
+	hud := c.BoolVar("hud", &p.HUD.Visible, "show the HUD")
+	hud.Archive = true
+
+	c.ApplyPending()
+}
+
+// applyFOV sets the fov variable on the current scene, if it is set.
+func (g *Game) applyFOV() {
+	if g.fov <= 0 {
+		return
+	}
+	if s, ok := g.Scenes.Current().(scene.Controllable); ok {
+		s.SetFOV(float32(g.fov))
+	}
+}
@@ -4,16 +4,106 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"log"
 	"remnant/internal/controller"
+	"remnant/pkg/camera"
+	"remnant/pkg/capture"
+	"remnant/pkg/console"
+	"remnant/pkg/hud"
+	"remnant/pkg/input"
+	"remnant/pkg/profile"
 	"remnant/pkg/program"
 	"remnant/pkg/scene"
+	"remnant/pkg/sdf"
+	"remnant/pkg/timing"
+	"remnant/pkg/watch"
+	"strings"
+	"time"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"gonum.org/v1/gonum/mat"
 )
 
+var errorColor = [4]float32{1, 0.35, 0.3, 1}
+
 type Game struct {
 	*controller.Controller
 	Window *glfw.Window
+	Scenes *scene.Manager
+
+	Recorder   *camera.Recorder
+	RecordFile string
+	Player     *camera.Player
+
+	Screenshots *capture.Screenshots
+	Sequence    *capture.Sequence
+	// SequenceFrames stops the game after this many captured frames, 0 runs
+	// until the window is closed
+	SequenceFrames int
+
+	// render quality, applied when the program is created
+	Quality           program.Preset
+	DynamicResolution bool
+	TargetFPS         float64
+	Temporal          bool
+	Debug             sdf.DebugMode
+	HUD               bool
+
+	watcher   *watch.Watcher
+	sceneFile string
+	// sceneName is the name the scene file is registered under
+	sceneName string
+	// reloadErr is the last shader compile failure, sceneErr the last scene
+	// file that failed to load
+	reloadErr error
+	sceneErr  error
+	program   *program.Program
+
+	screenshot bool
+	view       *capture.Metadata
+	// time is how long the scene has run, the time the shaders see
+	time float64
+
+	// VSync waits for the display refresh on swap. FrameCap limits the frame
+	// rate on top of that, 0 leaves it uncapped.
+	VSync    bool
+	FrameCap float64
+	Stats    *timing.Stats
+	summary  timing.Summary
+	Profiler *profile.Profiler
+	// TraceFile receives the profiler events as Chrome trace JSON on exit
+	TraceFile   string
+	showProfile bool
+	// StatsFile receives every frame time as CSV on exit
+	StatsFile string
+
+	// FullscreenMode is what ToggleFullscreen switches to from windowed
+	FullscreenMode WindowMode
+	Monitor        int
+	windowMode     WindowMode
+	windowed       [4]int
+
+	// Console runs commands and holds the variables set from the config
+	// file, the command line and the console itself. ConfigFile receives the
+	// archived variables on exit.
+	Console    *console.Console
+	ConfigFile string
+	// TimeScale multiplies the time scenes are updated with
+	TimeScale float64
+
+	lookCursor [2]float64
+	// maxSteps is the render panel's slider, stepsEdited whether it has
+	// been moved since it was last applied
+	maxSteps    int
+	stepsEdited bool
+
+	// console variables
+	fov    float64
+	steps  int
+	noclip bool
+
+	// current is the scene the last frame was drawn from
+	current scene.Scene
 }
 
 func NewGame(window *glfw.Window) *Game {
@@ -26,72 +116,644 @@ func NewGame(window *glfw.Window) *Game {
 	return &Game{
 		Window:     window,
 		Controller: ctrl,
+		Scenes:     scene.NewManager(0.5),
+
+		Screenshots: capture.NewScreenshots("screenshots"),
+		Quality:     program.Presets[2],
+		TargetFPS:   60,
+		Temporal:    true,
+		HUD:         true,
+
+		FullscreenMode: Borderless,
+		VSync:          true,
+		Stats:          timing.NewStats(300),
+		Profiler:       profile.New(),
+
+		Console:   console.New(),
+		TimeScale: 1,
 	}
 }
 
-func (g *Game) Load(scene scene.Scene) error {
+func (g *Game) Load() error {
 	g.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
-	g.Window.SetMouseButtonCallback(scene.MouseButtonCallback)
-	g.Window.SetCursorPosCallback(scene.MousePositionCallback)
-	g.Window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-		if key == glfw.KeyEscape && action == glfw.Press {
+	input.EnableRawMotion(g.Window)
+	input.Forward(g.Window, g.handleInput)
+	g.watchSize()
+
+	return nil
+}
+
+func (g *Game) handleInput(event input.Event) {
+	if g.Console.HandleEvent(event) {
+		return
+	}
+	if g.program != nil && g.program.UI.HandleEvent(event) {
+		return
+	}
+
+	if event.Kind == input.KeyEvent {
+		if event.Key == glfw.KeyEscape && event.Action == glfw.Press {
 			g.Window.SetShouldClose(true)
 		}
+
+		if event.Action == glfw.Press && g.program != nil {
+			switch event.Key {
+			case glfw.KeyP:
+				g.cyclePreset()
+				return
+			case glfw.KeyO:
+				g.program.Scaler.Auto = !g.program.Scaler.Auto
+				return
+			case glfw.KeyT:
+				g.program.Temporal.Enabled = !g.program.Temporal.Enabled
+				return
+			case glfw.KeyI:
+				g.showProfile = !g.showProfile
+				g.Profiler.Enabled = g.showProfile || g.Profiler.Trace
+				return
+			case glfw.KeyG:
+				g.program.Debug = g.program.Debug.Next()
+				return
+			case glfw.KeyH:
+				g.renderReference()
+				return
+			case glfw.KeyU:
+				g.program.HUD.Visible = !g.program.HUD.Visible
+				return
+			case glfw.KeyTab:
+				g.toggleUI()
+				return
+			}
+		}
+
+		if event.Key == glfw.KeyF12 && event.Action == glfw.Press {
+			g.screenshot = true
+			return
+		}
+
+		// F11 and Alt+Enter toggle fullscreen
+		if event.Action == glfw.Press && (event.Key == glfw.KeyF11 || event.Key == glfw.KeyEnter && event.Mods&glfw.ModAlt != 0) {
+			if err := g.ToggleFullscreen(); err != nil {
+				log.Println(err)
+			}
+			return
+		}
+
+		// F1..F10 switch to the registered scenes in name order
+		if event.Action == glfw.Press && event.Key >= glfw.KeyF1 && event.Key <= glfw.KeyF10 {
+			names := g.Scenes.Names()
+			if i := int(event.Key - glfw.KeyF1); i < len(names) {
+				if err := g.Scenes.LoadNamed(names[i]); err != nil {
+					log.Println(err)
+				}
+			}
+			return
+		}
 		g.IsReady = true
+	}
+
+	g.Scenes.HandleInput(event)
+}
+
+// RecordCameraPath samples the scene camera every interval seconds while the
+// game runs and saves the path to file when it exits.
+func (g *Game) RecordCameraPath(file string, interval float64) {
+	g.Recorder = camera.NewRecorder(interval)
+	g.RecordFile = file
+}
+
+// PlayCameraPath drives the scene camera along the path stored in file,
+// overriding whatever the scene does with it.
+func (g *Game) PlayCameraPath(file string, loop bool) error {
+	path, err := camera.LoadPath(file)
+	if err != nil {
+		return err
+	}
+
+	g.Player = camera.NewPlayer(path, loop)
+	return nil
+}
+
+// ShowView pins the scene camera, light and time to those a screenshot was
+// taken with.
+func (g *Game) ShowView(meta *capture.Metadata) {
+	g.view = meta
+}
+
+// RecordFrameTimes keeps every frame time and writes them to file as CSV
+// when the game exits.
+func (g *Game) RecordFrameTimes(file string) {
+	g.Stats.Record = true
+	g.StatsFile = file
+}
+
+// RecordTrace profiles every frame and writes the events to file as Chrome
+// trace JSON when the game exits.
+func (g *Game) RecordTrace(file string) {
+	g.Profiler.Enabled = true
+	g.Profiler.Trace = true
+	g.TraceFile = file
+}
+
+// toggleUI shows or hides the debug panels. The cursor is freed while they
+// are shown and put back where mouse look left it when they close, so the
+// view doesn't jump.
+func (g *Game) toggleUI() {
+	panels := g.program.UI
+	if panels.Visible {
+		panels.Hide()
+		g.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+		g.Window.SetCursorPos(g.lookCursor[0], g.lookCursor[1])
+		return
+	}
+
+	g.lookCursor[0], g.lookCursor[1] = g.Window.GetCursorPos()
+	g.Window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	panels.Visible = true
+}
+
+// panels declares the game's own debug panel.
+func (g *Game) panels() {
+	p := g.program
+	panels := p.UI
+	if !panels.Window("render", 10, 60, 260) {
+		return
+	}
+
+	panels.Checkbox("taa", &p.Temporal.Enabled)
+	panels.SliderFloat32("feedback", &p.Temporal.Feedback, 0.5, 0.98)
+	panels.Checkbox("auto scale", &p.Scaler.Auto)
+	panels.SliderFloat("scale", &p.Scaler.Scale, p.Scaler.MinScale, p.Scaler.MaxScale)
+
+	// until the slider is moved it follows the preset, which keys and the
+	// console change too. Recompiling is slow, wait for it to be let go.
+	if !g.stepsEdited && !panels.Dragging() {
+		g.maxSteps = p.Preset.MaxSteps
+	}
+	if panels.SliderInt("max steps", &g.maxSteps, 16, 512) {
+		g.stepsEdited = true
+	}
+	if g.stepsEdited && !panels.Dragging() {
+		g.stepsEdited = false
+		preset := p.Preset
+		preset.Name, preset.MaxSteps, preset.Scale = "custom", g.maxSteps, p.Scaler.Scale
+		g.reloadErr = p.ApplyPreset(preset)
+		if g.reloadErr != nil {
+			log.Println(g.reloadErr)
+		}
+	}
+
+	if panels.Button("debug: " + p.Debug.String()) {
+		p.Debug = p.Debug.Next()
+	}
+
+	for _, pass := range p.Post.Passes {
+		enabled := pass.Enabled()
+		if panels.Checkbox(pass.Name(), &enabled) {
+			pass.SetEnabled(enabled)
+		}
+	}
+	if tonemap := p.Post.Pass("tonemap"); tonemap != nil {
+		exposure := tonemap.Get("exposure")
+		if panels.SliderFloat32("exposure", &exposure, 0, 4) {
+			tonemap.Set("exposure", exposure)
+		}
+	}
+	panels.End()
+}
+
+// printHUD adds the game's own lines in the top right: frame timing, render
+// settings and, while the profiler overlay is shown, its legend. Reload
+// failures go in the top left, compile logs in full.
+func (g *Game) printHUD() {
+	h := g.program.HUD
+	for _, err := range []error{g.reloadErr, g.sceneErr} {
+		if err != nil {
+			h.PrintColor(hud.TopLeft, errorColor, err.Error())
+		}
+	}
+
+	h.Printf(hud.TopRight, "%.0f fps  %.1f ms  p99 %.1f ms", g.summary.FPS, g.summary.Avg*1000, g.summary.P99*1000)
+	h.Printf(hud.TopRight, "%s %d%%", g.program.Preset.Name, int(g.program.Scaler.Scale*100+0.5))
+	if g.program.Debug != sdf.DebugOff {
+		h.Printf(hud.TopRight, "debug %s", g.program.Debug)
+	}
+
+	if g.showProfile {
+		for i, t := range g.Profiler.Breakdown() {
+			c := profile.Color(i)
+			h.PrintColor(hud.TopRight, [4]float32{c[0], c[1], c[2], 1},
+				fmt.Sprintf("%s %s %.2f ms", t.Category, t.Name, float64(t.Average)/float64(time.Millisecond)))
+		}
+	}
+}
+
+// RecordSequence captures every frame to seq, running the game on a fixed
+// timestep of one capture frame so the result plays back at real speed
+// however long each frame took to render.
+func (g *Game) RecordSequence(seq *capture.Sequence, frames int) {
+	g.Sequence = seq
+	g.SequenceFrames = frames
+}
+
+func (g *Game) takeScreenshot() {
+	meta := &capture.Metadata{Time: g.time}
+	if named, ok := g.Scenes.Current().(scene.Named); ok {
+		meta.Scene = named.Name()
+		meta.Seed = named.Seed()
+	}
+	if view, ok := g.Scenes.Current().(scene.Viewpoint); ok {
+		meta.Camera = capture.NewCameraMeta(view.ViewCamera())
+		light := view.Lights().Position
+		meta.Light = [3]float64{light.AtVec(0), light.AtVec(1), light.AtVec(2)}
+	}
+
+	g.Screenshots.Take(g.ScreenWidth, g.ScreenHeight, meta)
+}
+
+// renderReference saves the current view as drawn by the CPU reference
+// renderer, at half resolution and in the current debug mode.
+func (g *Game) renderReference() {
+	world, ok := g.Scenes.Current().(scene.Reference)
+	view, ok2 := g.Scenes.Current().(scene.Viewpoint)
+	if !ok || !ok2 {
+		log.Println("reference: the current scene has no CPU mirror")
+		return
+	}
+
+	meta := &capture.Metadata{Time: g.time, Debug: g.program.Debug.String()}
+	if named, ok := g.Scenes.Current().(scene.Named); ok {
+		meta.Scene = named.Name()
+		meta.Seed = named.Seed()
+	}
+	meta.Camera = capture.NewCameraMeta(view.ViewCamera())
+	light := view.Lights().Position
+	meta.Light = [3]float64{light.AtVec(0), light.AtVec(1), light.AtVec(2)}
+
+	// the game carries on while the frame renders, work from copies
+	cam := program.NewCamera(mat.NewVecDense(3, nil), meta.Camera.FOV)
+	meta.Camera.Apply(cam)
+	lightPos := mat.VecDenseCopyOf(light)
+	// march as far as the shader does, or the steps view and misses differ
+	scn := *world.World()
+	scn.MaxSteps = g.program.Preset.MaxSteps
+	scn.SetQuality(g.program.Preset.Quality)
+	mode := g.program.Debug
+	w, h := g.ScreenWidth/2, g.ScreenHeight/2
+
+	g.Screenshots.Render(w, h, meta, func() image.Image {
+		return scn.Render(w, h, func(x, y float64) (*mat.VecDense, *mat.VecDense) {
+			return cam.ScreenRay(x, y, w, h)
+		}, lightPos, mode)
 	})
+}
 
+func (g *Game) captureFrame() {
+	var cam capture.CameraMeta
+	if view, ok := g.Scenes.Current().(scene.Viewpoint); ok {
+		cam = capture.NewCameraMeta(view.ViewCamera())
+	}
+
+	g.Sequence.Capture(g.ScreenWidth, g.ScreenHeight, cam)
+	if g.SequenceFrames > 0 && g.Sequence.Frames() >= g.SequenceFrames {
+		g.Window.SetShouldClose(true)
+	}
+}
+
+// Watch enables hot reloading of the shaders and, if sceneFile is not empty,
+// the scene file the current scene was built from.
+func (g *Game) Watch(sceneFile string) error {
+	g.watcher = watch.New(500 * time.Millisecond)
+	if err := g.watcher.Add("shaders/*.glsl", "shaders/lib/*.glsl", "shaders/post/*.glsl", "shaders/hud/*.glsl"); err != nil {
+		return err
+	}
+
+	if sceneFile != "" {
+		g.sceneFile = sceneFile
+		return g.watcher.Add(sceneFile)
+	}
+	return nil
+}
+
+// reload recompiles shaders and reapplies the scene file when they change on
+// disk. Failures leave the running program and scene untouched.
+func (g *Game) reload(program *program.Program) {
+	if g.watcher == nil {
+		return
+	}
+
+	// an editor saving several shaders at once recompiles them once
+	var shaders []string
+	for _, file := range g.watcher.Poll() {
+		if file != g.sceneFile {
+			shaders = append(shaders, file)
+			continue
+		}
+
+		g.sceneErr = g.reloadScene(file)
+		if g.sceneErr != nil {
+			log.Println(g.sceneErr)
+		} else {
+			log.Println("reloaded", file)
+		}
+	}
+	if len(shaders) == 0 {
+		return
+	}
+
+	err := program.Reload(g.Console)
+	g.reloadErr = err
+	if err != nil {
+		log.Println(err)
+	} else {
+		log.Println("reloaded", strings.Join(shaders, " "))
+	}
+}
+
+// RegisterScene makes a scene file loadable by its name. The name must not
+// already belong to another scene.
+func (g *Game) RegisterScene(desc *scene.File) error {
+	if desc.Name != g.sceneName && g.Scenes.Registered(desc.Name) {
+		return fmt.Errorf("scene name %q is already taken", desc.Name)
+	}
+	// a renamed scene is no longer loadable by its old name
+	if g.sceneName != "" {
+		g.Scenes.Unregister(g.sceneName)
+	}
+	g.sceneName = desc.Name
+	g.Scenes.Register(desc.Name, func() scene.Scene { return desc.Build(g.Controller) })
+	return nil
+}
+
+func (g *Game) reloadScene(file string) error {
+	desc, err := scene.LoadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if err := g.RegisterScene(desc); err != nil {
+		return err
+	}
+
+	// only the scene built from this file is changed in place, anything
+	// else picks the edit up the next time it is loaded
+	current := g.Scenes.Current()
+	if named, ok := current.(scene.Named); !ok || named.Name() != desc.Name {
+		return nil
+	}
+	if s, ok := current.(scene.Reloadable); ok {
+		s.Reload(desc)
+	}
 	return nil
 }
 
-func (g *Game) Run(window *glfw.Window, scene *scene.SceneB) error {
+// applyQuality sets up the render scale and shader quality of a new program.
+func (g *Game) applyQuality(p *program.Program) error {
+	p.Scaler.TargetFrameTime = 1 / g.TargetFPS
+	if err := p.ApplyPreset(g.Quality); err != nil {
+		return err
+	}
+	p.Scaler.Auto = g.DynamicResolution
+	p.Temporal.Enabled = g.Temporal
+	p.Debug = g.Debug
+	return nil
+}
+
+// cyclePreset switches to the next quality preset, keeping dynamic
+// resolution off so the preset's scale is what you see.
+func (g *Game) cyclePreset() {
+	next := 0
+	for i, preset := range program.Presets {
+		if preset.Name == g.program.Preset.Name {
+			next = (i + 1) % len(program.Presets)
+		}
+	}
+
+	g.reloadErr = g.program.ApplyPreset(program.Presets[next])
+	if g.reloadErr != nil {
+		log.Println(g.reloadErr)
+	}
+}
+
+func (g *Game) title(frames timing.Summary) string {
+	title := fmt.Sprintf("FPS: %.2f | %.1f ms p99 %.1f ms | %s %d%%", frames.FPS, frames.Avg*1000, frames.P99*1000,
+		g.program.Preset.Name, int(g.program.Scaler.Scale*100+0.5))
+	if g.program.Scaler.Auto {
+		title += " auto"
+	}
+	if g.program.Temporal.Enabled {
+		title += " | taa"
+	}
+	if g.program.Debug != sdf.DebugOff {
+		title += " | debug " + g.program.Debug.String()
+	}
+	for _, err := range []error{g.reloadErr, g.sceneErr} {
+		if err != nil {
+			// the title only has room for the first line, the HUD shows the rest
+			msg := strings.SplitN(err.Error(), "\n", 2)[0]
+			title += " | " + msg
+		}
+	}
+	return title
+}
+
+func (g *Game) Run() error {
+	window := g.Window
+
 	// Create the shader program
-	program := program.NewProgram(window)
+	program, err := program.NewProgram(window)
+	if err != nil {
+		return err
+	}
 	defer program.Delete()
 
-	// Load the texture data to the GPU
-	tx := scene.CreateDataTexture()
-	data := program.SetObjectsTextureData(tx)
+	g.program = program
+	program.Profiler = g.Profiler
+	defer g.Profiler.Delete()
+	if err := g.applyQuality(program); err != nil {
+		return err
+	}
+	program.HUD.Visible = g.HUD
+
+	if err := g.Console.Init(program.Preprocessor); err != nil {
+		return err
+	}
+	defer g.Console.Delete()
+	g.registerConsole()
+
+	g.Scenes.Program = program
+	defer g.Scenes.Clear()
 
 	// Set the clear color to black
 	program.SetClearColor(0.0, 0.0, 0.0, 1.0)
 
+	if g.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+	limiter := timing.NewLimiter(g.FrameCap)
+
 	// initialize mouse position to middle of screen
-	window.SetCursorPos(float64(g.ScreenWidth)/2, float64(g.ScreenHeight)/2)
+	window.SetCursorPos(float64(g.WindowWidth)/2, float64(g.WindowHeight)/2)
 
 	deltaTime := 0.0
 	seconds := 0.0
-	fps := 0.0
-	for !g.Window.ShouldClose() {
+	for !g.Window.ShouldClose() && !g.Scenes.Empty() {
+		// nothing to draw into while minimized, and no frame to profile
+		if g.ScreenWidth == 0 || g.ScreenHeight == 0 {
+			glfw.WaitEvents()
+			glfw.SetTime(0.0)
+			continue
+		}
+
+		g.Profiler.BeginFrame()
+		end := g.Profiler.CPU("reload")
+		g.reload(program)
+		end()
 
-		program.Clear()
+		end = g.Profiler.CPU("update")
+		// typing into the console or a text field doesn't fly the ship
+		input.Capture(g.Console.Open || program.UI.WantsKeyboard())
+		if err := g.Scenes.Advance(g.DeltaTime); err != nil {
+			return err
+		}
+		// picking and collisions see the terrain the shader draws
+		if world, ok := g.Scenes.Current().(scene.Reference); ok {
+			world.World().SetQuality(program.Preset.Quality)
+		}
+		if err := g.Scenes.Update(g.DeltaTime * g.TimeScale); err != nil {
+			return err
+		}
+		// a new scene starts with its own camera and physics, and is a cut
+		if current := g.Scenes.Current(); current != g.current {
+			g.current = current
+			g.noclip = false
+			g.applyFOV()
+			program.Temporal.Invalidate()
+		}
+
+		if view, ok := g.Scenes.Current().(scene.Viewpoint); ok {
+			if g.Player != nil {
+				g.Player.Apply(view.Camera(), g.DeltaTime)
+				if g.Player.Looped() {
+					program.Temporal.Invalidate()
+				}
+			}
+			if g.Recorder != nil {
+				g.Recorder.Record(view.Camera(), g.DeltaTime)
+			}
+			if g.view != nil {
+				g.view.Camera.Apply(view.Camera())
+				g.view.ApplyLight(view.Lights())
+				g.time = g.view.Time
+			}
+		}
+		end()
 
-		scene.Render(program)
+		// Render offscreen at the scaled resolution
+		end = g.Profiler.CPU("render")
+		if err := program.Begin(g.ScreenWidth, g.ScreenHeight); err != nil {
+			return err
+		}
 
 		// Update the shader uniforms
-		program.SetTime(float32(seconds))
-		program.SetResolution(g.ScreenWidth, g.ScreenHeight)
-		program.SetData(data)
-		program.SetLight(scene.Lights())
-		program.SetCamera(scene.Camera())
+		program.SetTime(float32(g.time))
+		program.SetFade(float32(g.Scenes.Fade()))
 
 		// Draw
-		program.Draw()
+		program.UI.Begin(g.ScreenWidth, g.ScreenHeight, g.PixelRatio())
+		if err := g.Scenes.Render(program); err != nil {
+			return err
+		}
+		g.panels()
+		if err := program.End(); err != nil {
+			return err
+		}
+		end()
+
+		// read the frame back before it is swapped away
+		end = g.Profiler.CPU("capture")
+		if g.screenshot {
+			g.screenshot = false
+			g.takeScreenshot()
+		}
+		if g.Sequence != nil {
+			g.captureFrame()
+		}
+		end()
+
+		g.printHUD()
+		program.DrawOverlays(g.PixelRatio())
+		if g.showProfile {
+			g.Profiler.DrawOverlay(g.ScreenWidth, g.ScreenHeight, time.Duration(float64(time.Second)/g.TargetFPS))
+		}
+		// the console goes over everything else
+		g.Console.Draw(g.ScreenWidth, g.ScreenHeight, g.PixelRatio())
+		program.GLProgram.Use()
+
+		// the scaler steers on what the frame cost, not on time spent
+		// waiting for vsync or the frame cap, so let the GPU catch up and
+		// measure before swapping
+		if program.Scaler.Auto {
+			program.Finish()
+		}
+		workTime := glfw.GetTime()
 
 		// Swap the buffers
+		end = g.Profiler.CPU("swap")
 		window.SwapBuffers()
 		glfw.PollEvents()
+		limiter.Wait()
+		end()
+		g.Profiler.EndFrame()
 
-		fps += 1.0
 		deltaTime = glfw.GetTime()
+		g.Stats.Add(deltaTime)
+		program.Scaler.Update(workTime)
+		if g.Sequence != nil {
+			// virtual time for the simulation, decoupled from how long the
+			// frame really took
+			deltaTime = g.Sequence.FrameTime()
+		}
+		g.DeltaTime = deltaTime
+		g.time += deltaTime * g.TimeScale
 		seconds += deltaTime
 		if seconds >= 1.0 {
-			window.SetTitle(fmt.Sprintf("FPS: %.2f", fps))
+			g.summary = g.Stats.Summary()
+			window.SetTitle(g.title(g.summary))
 			seconds = 0
-			fps = 0.0
 		}
 		glfw.SetTime(0.0)
 	}
 
+	g.Screenshots.Wait()
+	if g.ConfigFile != "" {
+		if err := g.Console.SaveConfig(g.ConfigFile); err != nil {
+			return err
+		}
+	}
+	log.Println(g.Stats.Summary())
+	if g.StatsFile != "" {
+		if err := g.Stats.SaveCSV(g.StatsFile); err != nil {
+			return err
+		}
+	}
+	if g.TraceFile != "" {
+		if err := g.Profiler.SaveTrace(g.TraceFile); err != nil {
+			return err
+		}
+	}
+	if g.Sequence != nil {
+		if err := g.Sequence.Close(); err != nil {
+			return err
+		}
+	}
+
+	if g.Recorder != nil {
+		return g.Recorder.Path.Save(g.RecordFile)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+type WindowMode int
+
+const (
+	Windowed WindowMode = iota
+	// Borderless covers a monitor with an undecorated window at the desktop
+	// video mode, so switching is instant.
+	Borderless
+	// Fullscreen takes the monitor over exclusively.
+	Fullscreen
+)
+
+var windowModeNames = []string{"windowed", "borderless", "fullscreen"}
+
+func (m WindowMode) String() string {
+	if int(m) < len(windowModeNames) {
+		return windowModeNames[m]
+	}
+	return fmt.Sprintf("WindowMode(%d)", int(m))
+}
+
+func ParseWindowMode(name string) (WindowMode, error) {
+	for i, n := range windowModeNames {
+		if strings.EqualFold(n, name) {
+			return WindowMode(i), nil
+		}
+	}
+	return Windowed, fmt.Errorf("unknown window mode %q", name)
+}
+
+// WindowMode returns the current mode.
+func (g *Game) WindowMode() WindowMode {
+	return g.windowMode
+}
+
+// SetWindowMode switches between windowed, borderless and exclusive
+// fullscreen on the given monitor index. Leaving windowed mode remembers the
+// window position and size to go back to.
+func (g *Game) SetWindowMode(mode WindowMode, monitor int) error {
+	monitors := glfw.GetMonitors()
+	if monitor < 0 || monitor >= len(monitors) {
+		return fmt.Errorf("monitor %d not found, %d connected", monitor, len(monitors))
+	}
+	m := monitors[monitor]
+	video := m.GetVideoMode()
+
+	if g.windowMode == Windowed && mode != Windowed {
+		x, y := g.Window.GetPos()
+		w, h := g.Window.GetSize()
+		g.windowed = [4]int{x, y, w, h}
+	}
+
+	switch mode {
+	case Windowed:
+		g.Window.SetAttrib(glfw.Decorated, glfw.True)
+		x, y, w, h := g.windowed[0], g.windowed[1], g.windowed[2], g.windowed[3]
+		g.Window.SetMonitor(nil, x, y, w, h, 0)
+	case Borderless:
+		g.Window.SetAttrib(glfw.Decorated, glfw.False)
+		x, y := m.GetPos()
+		g.Window.SetMonitor(nil, x, y, video.Width, video.Height, 0)
+	case Fullscreen:
+		g.Window.SetMonitor(m, 0, 0, video.Width, video.Height, video.RefreshRate)
+	default:
+		return fmt.Errorf("unknown window mode %v", mode)
+	}
+
+	g.windowMode = mode
+	g.Monitor = monitor
+	return nil
+}
+
+// ToggleFullscreen switches between windowed and FullscreenMode.
+func (g *Game) ToggleFullscreen() error {
+	if g.windowMode != Windowed {
+		return g.SetWindowMode(Windowed, g.Monitor)
+	}
+	return g.SetWindowMode(g.FullscreenMode, g.Monitor)
+}
+
+// watchSize keeps the controller in step with the window. Render targets
+// follow ScreenWidth and ScreenHeight on the next frame.
+func (g *Game) watchSize() {
+	w, h := g.Window.GetSize()
+	g.ResizeWindow(w, h)
+
+	g.Window.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
+		g.Resize(width, height)
+	})
+	g.Window.SetSizeCallback(func(_ *glfw.Window, width, height int) {
+		g.ResizeWindow(width, height)
+	})
+}
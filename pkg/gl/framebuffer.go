@@ -0,0 +1,82 @@
+package gl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Framebuffer is an offscreen render target with a single float color
+// texture.
+type Framebuffer struct {
+	Handle  uint32
+	Texture uint32
+	Width   int
+	Height  int
+}
+
+func NewFramebuffer(width, height int) (*Framebuffer, error) {
+	fb := &Framebuffer{}
+	gl.GenFramebuffers(1, &fb.Handle)
+	gl.GenTextures(1, &fb.Texture)
+
+	if err := fb.Resize(width, height); err != nil {
+		fb.Delete()
+		return nil, err
+	}
+	return fb, nil
+}
+
+// Resize reallocates the color texture. It is a no-op if the size is
+// unchanged.
+func (fb *Framebuffer) Resize(width, height int) error {
+	if width == fb.Width && height == fb.Height {
+		return nil
+	}
+
+	gl.BindTexture(gl.TEXTURE_2D, fb.Texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, int32(width), int32(height), 0, gl.RGBA, gl.FLOAT, nil)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.Handle)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, fb.Texture, 0)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("FRAMEBUFFER::INCOMPLETE: status 0x%x for %dx%d", status, width, height)
+	}
+
+	fb.Width, fb.Height = width, height
+	return nil
+}
+
+// Bind makes the framebuffer the render target and sets the viewport to cover
+// it.
+func (fb *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.Handle)
+	gl.Viewport(0, 0, int32(fb.Width), int32(fb.Height))
+}
+
+// BlitToScreen scales the framebuffer onto the default framebuffer with
+// linear filtering.
+func (fb *Framebuffer) BlitToScreen(width, height int) {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fb.Handle)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.BlitFramebuffer(0, 0, int32(fb.Width), int32(fb.Height), 0, 0, int32(width), int32(height), gl.COLOR_BUFFER_BIT, gl.LINEAR)
+	BindScreen(width, height)
+}
+
+func (fb *Framebuffer) Delete() {
+	gl.DeleteTextures(1, &fb.Texture)
+	gl.DeleteFramebuffers(1, &fb.Handle)
+}
+
+// BindScreen makes the window the render target again.
+func BindScreen(width, height int) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(width), int32(height))
+}
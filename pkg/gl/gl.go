@@ -1,6 +1,7 @@
 package gl
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -51,6 +52,7 @@ func NewGLProgram(shaders ...*Shader) (*GLProgram, error) {
 	prog.Attach(shaders...)
 
 	if err := prog.Link(); err != nil {
+		prog.Delete()
 		return nil, err
 	}
 
@@ -67,6 +69,7 @@ func NewShader(src string, sType uint32) (*Shader, error) {
 	err := getGlError(handle, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog,
 		"SHADER::COMPILE_FAILURE::")
 	if err != nil {
+		gl.DeleteShader(handle)
 		return nil, err
 	}
 	return &Shader{handle: handle}, nil
@@ -86,20 +89,52 @@ func NewShaderFromFile(file string, sType uint32) (*Shader, error) {
 	gl.CompileShader(handle)
 	err = getGlError(handle, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog, "SHADER::COMPILE_FAILURE::"+file)
 	if err != nil {
+		gl.DeleteShader(handle)
 		return nil, err
 	}
 
 	return &Shader{handle: handle}, nil
 }
 
-func CreateGLProgram() (*GLProgram, error) {
-	vertShader, err := NewShaderFromFile("shaders/vertex.glsl", gl.VERTEX_SHADER)
+// NewShaderFromSource compiles preprocessed code. Line numbers in the
+// compile log are mapped back to the original files.
+func NewShaderFromSource(src *Source, sType uint32) (*Shader, error) {
+	handle := gl.CreateShader(sType)
+	glSrc, freeFn := gl.Strs(src.Code + "\x00")
+	defer freeFn()
+
+	gl.ShaderSource(handle, 1, glSrc, nil)
+	gl.CompileShader(handle)
+	err := getGlError(handle, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog, "SHADER::COMPILE_FAILURE::"+src.File)
+	if err != nil {
+		gl.DeleteShader(handle)
+		return nil, errors.New(src.TranslateLog(err.Error()))
+	}
+
+	return &Shader{handle: handle}, nil
+}
+
+func NewShaderWithPreprocessor(pp *Preprocessor, file string, sType uint32) (*Shader, error) {
+	src, err := pp.Process(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewShaderFromSource(src, sType)
+}
+
+func CreateGLProgram(pp *Preprocessor) (*GLProgram, error) {
+	return CreateGLProgramFromFiles(pp, "shaders/vertex.glsl", "shaders/fragment.glsl")
+}
+
+func CreateGLProgramFromFiles(pp *Preprocessor, vertFile, fragFile string) (*GLProgram, error) {
+	vertShader, err := NewShaderWithPreprocessor(pp, vertFile, gl.VERTEX_SHADER)
 	if err != nil {
 		return nil, err
 	}
 
-	fragShader, err := NewShaderFromFile("shaders/fragment.glsl", gl.FRAGMENT_SHADER)
+	fragShader, err := NewShaderWithPreprocessor(pp, fragFile, gl.FRAGMENT_SHADER)
 	if err != nil {
+		vertShader.Delete()
 		return nil, err
 	}
 
@@ -0,0 +1,377 @@
+package gl
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const postVertexShader = "shaders/post/fullscreen.glsl"
+
+// PostPass is one step of the post-processing chain. It reads src and draws
+// into dst, both the size of the render target.
+type PostPass interface {
+	Name() string
+	Enabled() bool
+	SetEnabled(enabled bool)
+	// Set changes a parameter, usually a uniform of the pass's shader.
+	Set(param string, value float32)
+	Get(param string) float32
+	Apply(src, dst *Framebuffer)
+	// Stage recompiles the pass's shaders as part of r.
+	Stage(r *Reload) error
+	Delete()
+}
+
+// ShaderPass runs a fullscreen fragment shader over the previous pass. The
+// input is bound to the "source" sampler and params are set as float or int
+// uniforms of the same name.
+type ShaderPass struct {
+	name     string
+	file     string
+	enabled  bool
+	params   map[string]float32
+	textures map[string]uint32
+
+	program  *GLProgram
+	uniforms map[string]ActiveUniform
+}
+
+func NewShaderPass(pp *Preprocessor, name, file string, params map[string]float32) (*ShaderPass, error) {
+	pass := &ShaderPass{
+		name:     name,
+		file:     file,
+		enabled:  true,
+		params:   params,
+		textures: map[string]uint32{},
+	}
+	if pass.params == nil {
+		pass.params = map[string]float32{}
+	}
+
+	if err := Apply(pp, pass); err != nil {
+		return nil, err
+	}
+	return pass, nil
+}
+
+func (p *ShaderPass) Name() string {
+	return p.name
+}
+
+func (p *ShaderPass) Enabled() bool {
+	return p.enabled
+}
+
+func (p *ShaderPass) SetEnabled(enabled bool) {
+	p.enabled = enabled
+}
+
+func (p *ShaderPass) Set(param string, value float32) {
+	p.params[param] = value
+}
+
+func (p *ShaderPass) Get(param string) float32 {
+	return p.params[param]
+}
+
+func (p *ShaderPass) Program() *GLProgram {
+	return p.program
+}
+
+// Texture binds an extra input texture to the named sampler.
+func (p *ShaderPass) Texture(sampler string, texture uint32) {
+	p.textures[sampler] = texture
+}
+
+func (p *ShaderPass) Stage(r *Reload) error {
+	return r.Compile(postVertexShader, p.file, p.install)
+}
+
+func (p *ShaderPass) install(program *GLProgram) {
+	if p.program != nil {
+		p.program.Delete()
+	}
+	p.program = program
+	p.uniforms = map[string]ActiveUniform{}
+	for _, uniform := range program.ActiveUniforms() {
+		p.uniforms[uniform.Name] = uniform
+	}
+}
+
+func (p *ShaderPass) Apply(src, dst *Framebuffer) {
+	dst.Bind()
+	p.program.Use()
+
+	p.bindTexture("source", 0, src.Texture)
+	unit := uint32(1)
+	for sampler, texture := range p.textures {
+		p.bindTexture(sampler, unit, texture)
+		unit++
+	}
+
+	for name, value := range p.params {
+		uniform, ok := p.uniforms[name]
+		if !ok {
+			continue
+		}
+		if uniform.Type == gl.INT || uniform.Type == gl.BOOL {
+			gl.Uniform1i(uniform.Location, int32(value))
+		} else {
+			gl.Uniform1f(uniform.Location, value)
+		}
+	}
+
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+}
+
+func (p *ShaderPass) bindTexture(sampler string, unit, texture uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	if uniform, ok := p.uniforms[sampler]; ok {
+		gl.Uniform1i(uniform.Location, int32(unit))
+	}
+}
+
+func (p *ShaderPass) Delete() {
+	p.program.Delete()
+}
+
+// Bloom makes everything brighter than a threshold glow. The bright parts
+// are extracted and blurred at half resolution, then added back.
+//
+// Parameters: threshold, knee, intensity and iterations.
+type Bloom struct {
+	enabled    bool
+	iterations int
+
+	bright    *ShaderPass
+	blur      *ShaderPass
+	composite *ShaderPass
+	half      [2]*Framebuffer
+}
+
+func NewBloom(pp *Preprocessor) (*Bloom, error) {
+	b := &Bloom{enabled: true, iterations: 3}
+
+	var err error
+	if b.bright, err = NewShaderPass(pp, "bloom-bright", "shaders/post/bright.glsl", map[string]float32{"threshold": 1, "knee": 0.5}); err != nil {
+		return nil, err
+	}
+	if b.blur, err = NewShaderPass(pp, "bloom-blur", "shaders/post/blur.glsl", nil); err != nil {
+		b.bright.Delete()
+		return nil, err
+	}
+	if b.composite, err = NewShaderPass(pp, "bloom", "shaders/post/bloom.glsl", map[string]float32{"intensity": 0.6}); err != nil {
+		b.bright.Delete()
+		b.blur.Delete()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Bloom) Name() string {
+	return "bloom"
+}
+
+func (b *Bloom) Enabled() bool {
+	return b.enabled
+}
+
+func (b *Bloom) SetEnabled(enabled bool) {
+	b.enabled = enabled
+}
+
+func (b *Bloom) Set(param string, value float32) {
+	switch param {
+	case "iterations":
+		b.iterations = int(value)
+	case "intensity":
+		b.composite.Set(param, value)
+	default:
+		b.bright.Set(param, value)
+	}
+}
+
+func (b *Bloom) Get(param string) float32 {
+	switch param {
+	case "iterations":
+		return float32(b.iterations)
+	case "intensity":
+		return b.composite.Get(param)
+	}
+	return b.bright.Get(param)
+}
+
+func (b *Bloom) Apply(src, dst *Framebuffer) {
+	if err := b.resize(src.Width/2, src.Height/2); err != nil {
+		// without the half size buffers bloom is skipped for this frame
+		b.composite.Texture("bloom", 0)
+		b.composite.Apply(src, dst)
+		return
+	}
+
+	b.bright.Apply(src, b.half[0])
+	for i := 0; i < b.iterations; i++ {
+		b.blur.Set("horizontal", 1)
+		b.blur.Apply(b.half[0], b.half[1])
+		b.blur.Set("horizontal", 0)
+		b.blur.Apply(b.half[1], b.half[0])
+	}
+
+	b.composite.Texture("bloom", b.half[0].Texture)
+	b.composite.Apply(src, dst)
+}
+
+func (b *Bloom) resize(width, height int) error {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	for i := range b.half {
+		if b.half[i] == nil {
+			fb, err := NewFramebuffer(width, height)
+			if err != nil {
+				return err
+			}
+			b.half[i] = fb
+		} else if err := b.half[i].Resize(width, height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bloom) Stage(r *Reload) error {
+	for _, pass := range []*ShaderPass{b.bright, b.blur, b.composite} {
+		if err := pass.Stage(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bloom) Delete() {
+	b.bright.Delete()
+	b.blur.Delete()
+	b.composite.Delete()
+	for _, fb := range b.half {
+		if fb != nil {
+			fb.Delete()
+		}
+	}
+}
+
+// PostChain runs the enabled passes in order, ping-ponging between two
+// framebuffers the size of the input.
+type PostChain struct {
+	Passes []PostPass
+
+	vao     uint32
+	buffers [2]*Framebuffer
+}
+
+// NewPostChain builds the default stack: bloom, tonemapping, gamma
+// correction, FXAA and vignette.
+func NewPostChain(pp *Preprocessor) (*PostChain, error) {
+	c := &PostChain{}
+	gl.GenVertexArrays(1, &c.vao)
+
+	bloom, err := NewBloom(pp)
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+	c.Passes = append(c.Passes, bloom)
+
+	passes := []struct {
+		name, file string
+		params     map[string]float32
+	}{
+		{"tonemap", "shaders/post/tonemap.glsl", map[string]float32{"exposure": 1, "operator": 1}},
+		{"gamma", "shaders/post/gamma.glsl", map[string]float32{"gamma": 2.2}},
+		{"fxaa", "shaders/post/fxaa.glsl", map[string]float32{"span_max": 8, "reduce_mul": 1.0 / 8, "reduce_min": 1.0 / 128}},
+		{"vignette", "shaders/post/vignette.glsl", map[string]float32{"intensity": 0.4, "radius": 0.9, "softness": 0.6}},
+	}
+	for _, p := range passes {
+		pass, err := NewShaderPass(pp, p.name, p.file, p.params)
+		if err != nil {
+			c.Delete()
+			return nil, err
+		}
+		c.Passes = append(c.Passes, pass)
+	}
+
+	return c, nil
+}
+
+// Pass returns the pass with the given name, or nil.
+func (c *PostChain) Pass(name string) PostPass {
+	for _, pass := range c.Passes {
+		if pass.Name() == name {
+			return pass
+		}
+	}
+	return nil
+}
+
+// Apply runs the chain on src and returns the framebuffer holding the
+// result, which is src itself if every pass is disabled.
+func (c *PostChain) Apply(src *Framebuffer) (*Framebuffer, error) {
+	if err := c.resize(src.Width, src.Height); err != nil {
+		return src, err
+	}
+
+	gl.BindVertexArray(c.vao)
+	out := src
+	next := 0
+	for _, pass := range c.Passes {
+		if !pass.Enabled() {
+			continue
+		}
+		pass.Apply(out, c.buffers[next])
+		out = c.buffers[next]
+		next = 1 - next
+	}
+
+	return out, nil
+}
+
+func (c *PostChain) resize(width, height int) error {
+	for i := range c.buffers {
+		if c.buffers[i] == nil {
+			fb, err := NewFramebuffer(width, height)
+			if err != nil {
+				return err
+			}
+			c.buffers[i] = fb
+		} else if err := c.buffers[i].Resize(width, height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stage recompiles every pass as part of r.
+func (c *PostChain) Stage(r *Reload) error {
+	for _, pass := range c.Passes {
+		if err := pass.Stage(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PostChain) Delete() {
+	for _, pass := range c.Passes {
+		pass.Delete()
+	}
+	for _, fb := range c.buffers {
+		if fb != nil {
+			fb.Delete()
+		}
+	}
+	gl.DeleteVertexArrays(1, &c.vao)
+}
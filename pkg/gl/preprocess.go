@@ -0,0 +1,204 @@
+package gl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	includePattern = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"`)
+	versionPattern = regexp.MustCompile(`^\s*#\s*version\b`)
+
+	// compiler logs refer to lines as 0(12) on nvidia and 0:12 elsewhere
+	logLinePattern = regexp.MustCompile(`\b0(?:\((\d+)\)|:(\d+))`)
+)
+
+// Preprocessor expands #include "file" directives and injects #defines after
+// the #version line, keeping track of where every output line came from.
+type Preprocessor struct {
+	// IncludeDirs are searched, in order, after the including file's directory.
+	IncludeDirs []string
+	Defines     map[string]string
+}
+
+func NewPreprocessor(includeDirs ...string) *Preprocessor {
+	return &Preprocessor{
+		IncludeDirs: includeDirs,
+		Defines:     make(map[string]string),
+	}
+}
+
+func (p *Preprocessor) Define(name string, value interface{}) {
+	p.Defines[name] = fmt.Sprint(value)
+}
+
+// Source is preprocessed shader code with a map back to the original files.
+type Source struct {
+	File  string
+	Code  string
+	lines []origin
+}
+
+type origin struct {
+	file string
+	line int
+}
+
+// Process reads file and returns it with includes resolved. Each file is
+// included at most once, so libraries need no include guards.
+func (p *Preprocessor) Process(file string) (*Source, error) {
+	src := &Source{File: file}
+	out := &strings.Builder{}
+
+	included := map[string]bool{}
+	if err := p.expand(file, src, out, included, true); err != nil {
+		return nil, err
+	}
+
+	src.Code = out.String()
+	return src, nil
+}
+
+func (p *Preprocessor) expand(file string, src *Source, out *strings.Builder, included map[string]bool, root bool) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if included[abs] {
+		return nil
+	}
+	included[abs] = true
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	emit := func(line string, o origin) {
+		out.WriteString(line)
+		out.WriteByte('\n')
+		src.lines = append(src.lines, o)
+	}
+
+	// directives are only recognised outside comments
+	var code string
+	inComment, version := false, false
+	for i, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		here := origin{file: file, line: i + 1}
+		code, inComment = stripComments(line, inComment)
+
+		if versionPattern.MatchString(code) {
+			if !root {
+				return fmt.Errorf("%s:%d: #version is only allowed in the main shader file", file, i+1)
+			}
+			version = true
+			emit(line, here)
+			p.emitDefines(emit)
+			continue
+		}
+
+		if m := includePattern.FindStringSubmatch(code); m != nil {
+			path, err := p.resolve(m[1], filepath.Dir(file))
+			if err != nil {
+				return fmt.Errorf("%s:%d: %v", file, i+1, err)
+			}
+			if err := p.expand(path, src, out, included, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		emit(line, here)
+	}
+
+	// the defines go after #version, without it they would be lost
+	if root && !version {
+		return fmt.Errorf("%s: missing #version", file)
+	}
+	return nil
+}
+
+// stripComments removes the comments from line, given whether it starts
+// inside a block comment, and reports whether it ends inside one.
+func stripComments(line string, inComment bool) (string, bool) {
+	var code strings.Builder
+	for len(line) > 0 {
+		if inComment {
+			end := strings.Index(line, "*/")
+			if end < 0 {
+				return code.String(), true
+			}
+			line = line[end+2:]
+			inComment = false
+			code.WriteByte(' ')
+			continue
+		}
+
+		block, comment := strings.Index(line, "/*"), strings.Index(line, "//")
+		if comment >= 0 && (block < 0 || comment < block) {
+			code.WriteString(line[:comment])
+			return code.String(), false
+		}
+		if block < 0 {
+			code.WriteString(line)
+			break
+		}
+		code.WriteString(line[:block])
+		line = line[block+2:]
+		inComment = true
+	}
+	return code.String(), inComment
+}
+
+func (p *Preprocessor) emitDefines(emit func(string, origin)) {
+	names := make([]string, 0, len(p.Defines))
+	for name := range p.Defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		emit("#define "+name+" "+p.Defines[name], origin{file: "<defines>", line: i + 1})
+	}
+}
+
+func (p *Preprocessor) resolve(name, dir string) (string, error) {
+	for _, d := range append([]string{dir}, p.IncludeDirs...) {
+		path := filepath.Join(d, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find include %q", name)
+}
+
+// Origin maps a 1-based line of the processed code back to its file and line.
+func (s *Source) Origin(line int) (string, int) {
+	if line < 1 || line > len(s.lines) {
+		return s.File, line
+	}
+	o := s.lines[line-1]
+	return o.file, o.line
+}
+
+// TranslateLog rewrites line references in a compiler log to file:line.
+func (s *Source) TranslateLog(log string) string {
+	return logLinePattern.ReplaceAllStringFunc(log, func(ref string) string {
+		m := logLinePattern.FindStringSubmatch(ref)
+		n := m[1]
+		if n == "" {
+			n = m[2]
+		}
+		line, err := strconv.Atoi(n)
+		if err != nil {
+			return ref
+		}
+		file, orig := s.Origin(line)
+		return fmt.Sprintf("%s:%d", file, orig)
+	})
+}
@@ -0,0 +1,240 @@
+package gl_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	glprogram "remnant/pkg/gl"
+)
+
+// writeFiles creates files, relative to a new temporary directory, and
+// returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPreprocessIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.glsl": `#version 410
+#include "a.glsl"
+#include "b.glsl"
+void main() {}`,
+		"a.glsl": `#include "lib/common.glsl"
+float a;`,
+		"b.glsl": `#include "lib/common.glsl"
+float b;`,
+		"lib/common.glsl": `#include "../a.glsl"
+float common;`,
+	})
+
+	pp := glprogram.NewPreprocessor()
+	pp.Define("QUALITY", 2)
+	pp.Define("MAX_STEPS", 128)
+	src, err := pp.Process(filepath.Join(dir, "main.glsl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every file once, in the order it is first reached, and the cycle back
+	// to a.glsl is harmless
+	want := `#version 410
+#define MAX_STEPS 128
+#define QUALITY 2
+float common;
+float a;
+float b;
+void main() {}
+`
+	if src.Code != want {
+		t.Errorf("code:\n%s\nwant:\n%s", src.Code, want)
+	}
+
+	for _, c := range []struct {
+		line int
+		file string
+		orig int
+	}{
+		{1, "main.glsl", 1},
+		{2, "<defines>", 1},
+		{3, "<defines>", 2},
+		{4, "lib/common.glsl", 2},
+		{5, "a.glsl", 2},
+		{6, "b.glsl", 2},
+		{7, "main.glsl", 4},
+	} {
+		file, orig := src.Origin(c.line)
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = filepath.ToSlash(rel)
+		}
+		if file != c.file || orig != c.orig {
+			t.Errorf("line %d from %s:%d, want %s:%d", c.line, file, orig, c.file, c.orig)
+		}
+	}
+}
+
+func TestPreprocessIncludeDirs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shaders/main.glsl": `#version 410
+#include "frame.glsl"`,
+		"shaders/lib/frame.glsl": `float frame;`,
+	})
+
+	pp := glprogram.NewPreprocessor(filepath.Join(dir, "shaders/lib"))
+	src, err := pp.Process(filepath.Join(dir, "shaders/main.glsl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(src.Code, "float frame;") {
+		t.Errorf("include dir not searched:\n%s", src.Code)
+	}
+}
+
+func TestPreprocessIgnoresCommentedIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.glsl": `#version 410
+// #include "missing.glsl"
+/* #include "missing.glsl" */
+/*
+#include "missing.glsl"
+*/
+/* a */ #include "lib.glsl" // included
+void main() {}`,
+		"lib.glsl": `float lib;`,
+	})
+
+	src, err := glprogram.NewPreprocessor().Process(filepath.Join(dir, "main.glsl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(src.Code, "float lib;") != 1 {
+		t.Errorf("include after a comment not expanded:\n%s", src.Code)
+	}
+	if strings.Count(src.Code, `#include "missing.glsl"`) != 3 {
+		t.Errorf("commented includes not left as they were:\n%s", src.Code)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "missing version",
+			files: map[string]string{"main.glsl": `void main() {}`},
+			want:  "main.glsl: missing #version",
+		},
+		{
+			name:  "commented version",
+			files: map[string]string{"main.glsl": "/* #version 410 */\nvoid main() {}"},
+			want:  "main.glsl: missing #version",
+		},
+		{
+			name: "version in an include",
+			files: map[string]string{
+				"main.glsl": "#version 410\n#include \"lib.glsl\"",
+				"lib.glsl":  "float lib;\n#version 410",
+			},
+			want: "lib.glsl:2: #version is only allowed in the main shader file",
+		},
+		{
+			name:  "missing include",
+			files: map[string]string{"main.glsl": "#version 410\n\n#include \"missing.glsl\""},
+			want:  `main.glsl:3: cannot find include "missing.glsl"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := glprogram.NewPreprocessor().Process(filepath.Join(dir, "main.glsl"))
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if got := strings.TrimPrefix(err.Error(), dir+string(filepath.Separator)); got != tt.want {
+				t.Errorf("error %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateLog(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.glsl": `#version 410
+#include "lib.glsl"
+void main() {
+    x = 1;
+}`,
+		"lib.glsl": `float lib;
+float broken`,
+	})
+
+	pp := glprogram.NewPreprocessor()
+	pp.Define("QUALITY", 1)
+	main := filepath.Join(dir, "main.glsl")
+	src, err := pp.Process(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := filepath.Join(dir, "lib.glsl")
+
+	tests := []struct {
+		name string
+		log  string
+		want string
+	}{
+		{
+			name: "colon",
+			log:  "ERROR: 0:6: 'x' : undeclared identifier",
+			want: "ERROR: " + main + ":4: 'x' : undeclared identifier",
+		},
+		{
+			name: "nvidia",
+			log:  "0(4) : error C0000: syntax error",
+			want: lib + ":2 : error C0000: syntax error",
+		},
+		{
+			name: "several lines",
+			log:  "ERROR: 0:4: a\nERROR: 0:1: b",
+			want: "ERROR: " + lib + ":2: a\nERROR: " + main + ":1: b",
+		},
+		{
+			name: "define",
+			log:  "ERROR: 0:2: bad define",
+			want: "ERROR: <defines>:1: bad define",
+		},
+		{
+			name: "out of range",
+			log:  "ERROR: 0:99: past the end",
+			want: "ERROR: " + main + ":99: past the end",
+		},
+		{
+			name: "no line",
+			log:  "ERROR: 10 compilation errors",
+			want: "ERROR: 10 compilation errors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := src.TranslateLog(tt.log); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package gl
+
+// Reload recompiles a set of programs as one: Compile builds each
+// replacement without touching the program in use, Commit swaps them all in
+// and Discard throws them away. A failed compile anywhere leaves every
+// program running as it was.
+type Reload struct {
+	pp     *Preprocessor
+	staged []staged
+}
+
+type staged struct {
+	program *GLProgram
+	install func(*GLProgram)
+}
+
+// Stager is anything with programs to recompile as part of a Reload.
+type Stager interface {
+	Stage(r *Reload) error
+}
+
+func NewReload(pp *Preprocessor) *Reload {
+	return &Reload{pp: pp}
+}
+
+// Compile builds a program from vertFile and fragFile. install receives it
+// on Commit and is responsible for deleting the program it replaces.
+func (r *Reload) Compile(vertFile, fragFile string, install func(*GLProgram)) error {
+	program, err := CreateGLProgramFromFiles(r.pp, vertFile, fragFile)
+	if err != nil {
+		return err
+	}
+	r.staged = append(r.staged, staged{program, install})
+	return nil
+}
+
+func (r *Reload) Commit() {
+	for _, s := range r.staged {
+		s.install(s.program)
+	}
+	r.staged = nil
+}
+
+func (r *Reload) Discard() {
+	for _, s := range r.staged {
+		s.program.Delete()
+	}
+	r.staged = nil
+}
+
+// Apply stages everything in stagers and commits only if all of them
+// compiled.
+func Apply(pp *Preprocessor, stagers ...Stager) error {
+	r := NewReload(pp)
+	for _, s := range stagers {
+		if err := s.Stage(r); err != nil {
+			r.Discard()
+			return err
+		}
+	}
+	r.Commit()
+	return nil
+}
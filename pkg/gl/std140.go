@@ -0,0 +1,116 @@
+package gl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// AppendStd140 appends v, a struct, encoded with the std140 layout rules of
+// uniform blocks:
+//
+//   - float32, int32, uint32 and bool are 4 byte scalars
+//   - arrays of 2 to 4 scalars are vectors, vec2 aligned to 8 bytes and
+//     vec3/vec4 to 16; a vec3 leaves room for a following scalar
+//   - any other array, including [4][4]float32 matrices, has its elements
+//     aligned and padded to 16 bytes
+//   - nested structs are aligned and padded to 16 bytes
+func AppendStd140(buf []byte, v interface{}) ([]byte, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return buf, fmt.Errorf("std140: %T is not a struct", v)
+	}
+
+	e := &std140{buf: buf, start: len(buf)}
+	if err := e.encode(value); err != nil {
+		return buf, err
+	}
+	return e.buf, nil
+}
+
+// Std140Size returns the size in bytes of v encoded with AppendStd140.
+func Std140Size(v interface{}) (int, error) {
+	buf, err := AppendStd140(nil, v)
+	return len(buf), err
+}
+
+type std140 struct {
+	buf   []byte
+	start int
+}
+
+func (e *std140) align(n int) {
+	for (len(e.buf)-e.start)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *std140) encode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		e.align(4)
+		e.scalar(v)
+
+	case reflect.Array:
+		n := v.Len()
+		if isScalar(v.Type().Elem().Kind()) && n >= 2 && n <= 4 {
+			if n == 2 {
+				e.align(8)
+			} else {
+				e.align(16)
+			}
+			for i := 0; i < n; i++ {
+				e.scalar(v.Index(i))
+			}
+			return nil
+		}
+
+		for i := 0; i < n; i++ {
+			e.align(16)
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.align(16)
+
+	case reflect.Struct:
+		e.align(16)
+		for i := 0; i < v.NumField(); i++ {
+			if err := e.encode(v.Field(i)); err != nil {
+				return fmt.Errorf("%s: %w", v.Type().Field(i).Name, err)
+			}
+		}
+		e.align(16)
+
+	default:
+		return fmt.Errorf("std140: unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func (e *std140) scalar(v reflect.Value) {
+	var bits uint32
+	switch v.Kind() {
+	case reflect.Float32:
+		bits = math.Float32bits(float32(v.Float()))
+	case reflect.Int32:
+		bits = uint32(int32(v.Int()))
+	case reflect.Uint32:
+		bits = uint32(v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			bits = 1
+		}
+	}
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, bits)
+}
+
+func isScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return true
+	}
+	return false
+}
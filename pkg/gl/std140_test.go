@@ -0,0 +1,301 @@
+package gl_test
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	glprogram "remnant/pkg/gl"
+	"remnant/pkg/program"
+)
+
+// word is a 4 byte value expected at an offset of the encoded block.
+type word struct {
+	offset int
+	bits   uint32
+}
+
+func f(x float32) uint32 {
+	return math.Float32bits(x)
+}
+
+func TestStd140Packing(t *testing.T) {
+	type inner struct {
+		X float32
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		size  int
+		words []word
+	}{
+		{
+			name: "vec3 then float packs into the vec3's padding",
+			value: struct {
+				A [3]float32
+				B float32
+			}{[3]float32{1, 2, 3}, 4},
+			size:  16,
+			words: []word{{0, f(1)}, {4, f(2)}, {8, f(3)}, {12, f(4)}},
+		},
+		{
+			name: "vec2 aligned to 8",
+			value: struct {
+				A float32
+				B [2]float32
+			}{1, [2]float32{2, 3}},
+			size:  16,
+			words: []word{{0, f(1)}, {8, f(2)}, {12, f(3)}},
+		},
+		{
+			name: "vec4 aligned to 16",
+			value: struct {
+				A float32
+				B [4]float32
+			}{1, [4]float32{2, 3, 4, 5}},
+			size:  32,
+			words: []word{{0, f(1)}, {16, f(2)}, {28, f(5)}},
+		},
+		{
+			name: "scalar array has a 16 byte stride",
+			value: struct {
+				A [5]float32
+				B float32
+			}{[5]float32{1, 2, 3, 4, 5}, 6},
+			size:  96,
+			words: []word{{0, f(1)}, {16, f(2)}, {32, f(3)}, {48, f(4)}, {64, f(5)}, {80, f(6)}},
+		},
+		{
+			name: "int array has a 16 byte stride",
+			value: struct {
+				A [5]int32
+			}{[5]int32{-1, 2, 3, 4, 5}},
+			size:  80,
+			words: []word{{0, 0xffffffff}, {16, 2}, {64, 5}},
+		},
+		{
+			name: "mat3 columns are 16 bytes apart",
+			value: struct {
+				M [3][3]float32
+				B float32
+			}{[3][3]float32{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, 10},
+			size:  64,
+			words: []word{{0, f(1)}, {8, f(3)}, {12, 0}, {16, f(4)}, {32, f(7)}, {40, f(9)}, {48, f(10)}},
+		},
+		{
+			name: "mat4 columns are 16 bytes apart",
+			value: struct {
+				M [4][4]float32
+			}{[4][4]float32{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13, 14, 15, 16}}},
+			size:  64,
+			words: []word{{0, f(1)}, {16, f(5)}, {32, f(9)}, {48, f(13)}, {60, f(16)}},
+		},
+		{
+			name: "nested struct aligned and padded to 16",
+			value: struct {
+				A float32
+				S inner
+				B float32
+			}{1, inner{2}, 3},
+			size:  48,
+			words: []word{{0, f(1)}, {16, f(2)}, {32, f(3)}},
+		},
+		{
+			name: "bool is a 4 byte scalar",
+			value: struct {
+				A bool
+				B uint32
+			}{true, 7},
+			size:  16,
+			words: []word{{0, 1}, {4, 7}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := glprogram.AppendStd140(nil, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buf) != tt.size {
+				t.Errorf("size %d, want %d", len(buf), tt.size)
+			}
+			for _, w := range tt.words {
+				if w.offset+4 > len(buf) {
+					t.Errorf("offset %d beyond %d bytes", w.offset, len(buf))
+					continue
+				}
+				if got := binary.LittleEndian.Uint32(buf[w.offset:]); got != w.bits {
+					t.Errorf("offset %d: %#x, want %#x", w.offset, got, w.bits)
+				}
+			}
+		})
+	}
+}
+
+func TestStd140Appends(t *testing.T) {
+	prefix := []byte{0xaa, 0xbb}
+	buf, err := glprogram.AppendStd140(prefix, &struct {
+		A float32
+		B [2]float32
+	}{1, [2]float32{2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// alignment is relative to the start of the block, not the buffer
+	if len(buf) != 2+16 || buf[0] != 0xaa || buf[1] != 0xbb {
+		t.Fatalf("prefix not kept: % x", buf)
+	}
+	if got := binary.LittleEndian.Uint32(buf[2+8:]); got != f(2) {
+		t.Errorf("vec2 at %#x, want %#x", got, f(2))
+	}
+}
+
+func TestStd140Unsupported(t *testing.T) {
+	for _, v := range []interface{}{
+		1.5,
+		struct{ A float64 }{1},
+		struct{ A []float32 }{nil},
+	} {
+		if _, err := glprogram.AppendStd140(nil, v); err == nil {
+			t.Errorf("%T encoded without an error", v)
+		}
+	}
+}
+
+// glslSizes gives the std140 size and alignment of the member types used in
+// frame.glsl.
+var glslSizes = map[string][2]int{
+	"float": {4, 4},
+	"int":   {4, 4},
+	"uint":  {4, 4},
+	"bool":  {4, 4},
+	"vec2":  {8, 8},
+	"vec3":  {12, 16},
+	"vec4":  {16, 16},
+}
+
+var (
+	blockPattern  = regexp.MustCompile(`uniform\s+(\w+)\s*\{([^}]*)\}`)
+	memberPattern = regexp.MustCompile(`(?m)^\s*(\w+)\s+(\w+)\s*;`)
+)
+
+// glslOffsets returns the member offsets and total size of every uniform
+// block declared in src.
+func glslOffsets(t *testing.T, src string) map[string][]int {
+	t.Helper()
+
+	blocks := map[string][]int{}
+	for _, block := range blockPattern.FindAllStringSubmatch(src, -1) {
+		offset := 0
+		var offsets []int
+		for _, member := range memberPattern.FindAllStringSubmatch(block[2], -1) {
+			layout, ok := glslSizes[member[1]]
+			if !ok {
+				t.Fatalf("%s.%s: unknown type %s", block[1], member[2], member[1])
+			}
+			offset = (offset + layout[1] - 1) / layout[1] * layout[1]
+			offsets = append(offsets, offset)
+			offset += layout[0]
+		}
+		// the block itself is padded to a vec4
+		offsets = append(offsets, (offset+15)/16*16)
+		blocks[block[1]] = offsets
+	}
+	return blocks
+}
+
+// goOffsets encodes v once per field with only that field set and returns
+// where it landed, followed by the encoded size.
+func goOffsets(t *testing.T, v interface{}) []int {
+	t.Helper()
+
+	typ := reflect.TypeOf(v)
+	var offsets []int
+	for i := 0; i < typ.NumField(); i++ {
+		value := reflect.New(typ).Elem()
+		field := value.Field(i)
+		if field.Kind() == reflect.Array {
+			field = field.Index(0)
+		}
+		switch field.Kind() {
+		case reflect.Float32:
+			field.SetFloat(1)
+		case reflect.Int32:
+			field.SetInt(1)
+		case reflect.Bool:
+			field.SetBool(true)
+		default:
+			t.Fatalf("%s.%s: unexpected kind %s", typ.Name(), typ.Field(i).Name, field.Kind())
+		}
+
+		buf, err := glprogram.AppendStd140(nil, value.Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset := -1
+		for o := 0; o+4 <= len(buf); o += 4 {
+			if binary.LittleEndian.Uint32(buf[o:]) != 0 {
+				offset = o
+				break
+			}
+		}
+		offsets = append(offsets, offset)
+	}
+
+	size, err := glprogram.Std140Size(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(offsets, size)
+}
+
+func TestFrameBlocksMatchShader(t *testing.T) {
+	src, err := os.ReadFile("../../shaders/lib/frame.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shader := glslOffsets(t, string(src))
+
+	for name, v := range map[string]interface{}{
+		"FrameData":          program.FrameData{},
+		"CameraData":         program.CameraData{},
+		"PreviousCameraData": program.CameraData{},
+		"LightData":          program.LightData{},
+	} {
+		want, ok := shader[name]
+		if !ok {
+			t.Errorf("%s is not declared in frame.glsl", name)
+			continue
+		}
+		got := goOffsets(t, v)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: offsets and size %v, frame.glsl has %v", name, got, want)
+		}
+	}
+
+	// pinned so a change to either side shows up as a diff here
+	for _, c := range []struct {
+		name string
+		v    interface{}
+		want []int
+	}{
+		{"FrameData", program.FrameData{}, []int{0, 8, 16, 24, 28, 32}},
+		{"CameraData", program.CameraData{}, []int{0, 12, 16, 32, 48}},
+		{"LightData", program.LightData{}, []int{0, 16}},
+	} {
+		if got := goOffsets(t, c.v); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: offsets and size %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if strings.Count(string(src), "layout(std140)") != len(shader) {
+		t.Errorf("frame.glsl declares %d std140 blocks, parsed %d", strings.Count(string(src), "layout(std140)"), len(shader))
+	}
+}
@@ -0,0 +1,242 @@
+package gl
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	textVertexShader   = "shaders/hud/text_vertex.glsl"
+	textFragmentShader = "shaders/hud/text.glsl"
+)
+
+// Size of a glyph of the built in font in pixels at scale 1.
+const (
+	GlyphWidth  = 6
+	GlyphHeight = 13
+)
+
+// Layout of the font atlas: atlasColumns glyphs to a row, with room for
+// every glyph and the solid slot.
+const (
+	atlasColumns = 16
+	atlasRows    = (len(fixedGlyphs) + atlasColumns) / atlasColumns
+	atlasWidth   = float32(atlasColumns * GlyphWidth)
+	atlasHeight  = float32(atlasRows * GlyphHeight)
+)
+
+// floats per vertex: position, uv and tint
+const textVertexSize = 2 + 2 + 4
+
+// Text draws strings in the built in bitmap font, and solid rectangles for
+// backgrounds. Both are queued in window pixels with the origin in the top
+// left corner and drawn together, in order, by Flush, blended over whatever
+// is in the bound framebuffer.
+type Text struct {
+	// Shadow draws every string a second time, dark and one texel down and
+	// right, so it stays readable over bright backgrounds.
+	Shadow bool
+
+	program  *GLProgram
+	uniforms map[string]ActiveUniform
+	atlas    uint32
+	vao      uint32
+	vbo      uint32
+	vertices []float32
+}
+
+func NewText(pp *Preprocessor) (*Text, error) {
+	t := &Text{Shadow: true}
+	if err := Apply(pp, t); err != nil {
+		return nil, err
+	}
+
+	t.atlas = createFontAtlas()
+
+	gl.GenVertexArrays(1, &t.vao)
+	gl.GenBuffers(1, &t.vbo)
+	gl.BindVertexArray(t.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, textVertexSize*4, nil)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, textVertexSize*4, gl.PtrOffset(2*4))
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(2, 4, gl.FLOAT, false, textVertexSize*4, gl.PtrOffset(4*4))
+	gl.EnableVertexAttribArray(2)
+
+	return t, nil
+}
+
+// solidGlyph is the atlas slot after the last glyph, filled in completely
+// for Rect.
+const solidGlyph = len(fixedGlyphs)
+
+// createFontAtlas lays the glyphs out in a single channel texture,
+// atlasColumns to a row.
+func createFontAtlas() uint32 {
+	width := atlasColumns * GlyphWidth
+	height := atlasRows * GlyphHeight
+
+	pixels := make([]uint8, width*height)
+	for i, glyph := range fixedGlyphs {
+		x0 := i % atlasColumns * GlyphWidth
+		y0 := i / atlasColumns * GlyphHeight
+		for y, row := range glyph {
+			for x := 0; x < GlyphWidth; x++ {
+				if row&(1<<(GlyphWidth-1-x)) != 0 {
+					pixels[(y0+y)*width+x0+x] = 255
+				}
+			}
+		}
+	}
+	x0 := solidGlyph % atlasColumns * GlyphWidth
+	y0 := solidGlyph / atlasColumns * GlyphHeight
+	for y := 0; y < GlyphHeight; y++ {
+		for x := 0; x < GlyphWidth; x++ {
+			pixels[(y0+y)*width+x0+x] = 255
+		}
+	}
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(width), int32(height), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	// nearest keeps the pixels crisp at integer scales
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	return texture
+}
+
+func (t *Text) Stage(r *Reload) error {
+	return r.Compile(textVertexShader, textFragmentShader, t.install)
+}
+
+func (t *Text) install(program *GLProgram) {
+	if t.program != nil {
+		t.program.Delete()
+	}
+	t.program = program
+	t.uniforms = map[string]ActiveUniform{}
+	for _, uniform := range program.ActiveUniforms() {
+		t.uniforms[uniform.Name] = uniform
+	}
+}
+
+// Measure returns the size of s in pixels at scale. Lines are split on
+// newlines, every rune is one glyph wide.
+func Measure(s string, scale float32) (width, height float32) {
+	lines := strings.Split(s, "\n")
+	longest := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > longest {
+			longest = n
+		}
+	}
+	return float32(longest*GlyphWidth) * scale, float32(len(lines)*GlyphHeight) * scale
+}
+
+// Draw queues s with its top left corner at (x, y). Characters outside
+// printable ASCII are drawn as '?'.
+func (t *Text) Draw(x, y, scale float32, color [4]float32, s string) {
+	if t.Shadow {
+		t.queue(x+scale, y+scale, scale, [4]float32{0, 0, 0, color[3] * 0.8}, s)
+	}
+	t.queue(x, y, scale, color, s)
+}
+
+// Rect queues a filled rectangle with its top left corner at (x, y).
+func (t *Text) Rect(x, y, w, h float32, color [4]float32) {
+	// sample the middle of the solid slot so filtering never reaches a glyph
+	u, v := glyphUV(solidGlyph)
+	u += GlyphWidth / 2 / atlasWidth
+	v += GlyphHeight / 2 / atlasHeight
+
+	t.vertex(x, y, u, v, color)
+	t.vertex(x+w, y, u, v, color)
+	t.vertex(x, y+h, u, v, color)
+	t.vertex(x+w, y, u, v, color)
+	t.vertex(x+w, y+h, u, v, color)
+	t.vertex(x, y+h, u, v, color)
+}
+
+// glyphUV returns the texture coordinates of the top left corner of atlas
+// slot i.
+func glyphUV(i int) (u, v float32) {
+	return float32(i%atlasColumns*GlyphWidth) / atlasWidth, float32(i/atlasColumns*GlyphHeight) / atlasHeight
+}
+
+func (t *Text) queue(x, y, scale float32, color [4]float32, s string) {
+	w, h := GlyphWidth*scale, GlyphHeight*scale
+
+	cx, cy := x, y
+	for _, r := range s {
+		if r == '\n' {
+			cx, cy = x, cy+h
+			continue
+		}
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+
+		if r != ' ' {
+			u0, v0 := glyphUV(int(r - ' '))
+			u1 := u0 + GlyphWidth/atlasWidth
+			v1 := v0 + GlyphHeight/atlasHeight
+
+			t.vertex(cx, cy, u0, v0, color)
+			t.vertex(cx+w, cy, u1, v0, color)
+			t.vertex(cx, cy+h, u0, v1, color)
+			t.vertex(cx+w, cy, u1, v0, color)
+			t.vertex(cx+w, cy+h, u1, v1, color)
+			t.vertex(cx, cy+h, u0, v1, color)
+		}
+		cx += w
+	}
+}
+
+func (t *Text) vertex(x, y, u, v float32, color [4]float32) {
+	t.vertices = append(t.vertices, x, y, u, v, color[0], color[1], color[2], color[3])
+}
+
+// Flush draws everything queued since the last Flush into the bound
+// framebuffer, which is width by height pixels.
+func (t *Text) Flush(width, height int) {
+	if len(t.vertices) == 0 {
+		return
+	}
+
+	t.program.Use()
+	if uniform, ok := t.uniforms["screen"]; ok {
+		gl.Uniform2f(uniform.Location, float32(width), float32(height))
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, t.atlas)
+	if uniform, ok := t.uniforms["atlas"]; ok {
+		gl.Uniform1i(uniform.Location, 0)
+	}
+
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	gl.BindVertexArray(t.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(t.vertices)*4, gl.Ptr(t.vertices), gl.STREAM_DRAW)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(t.vertices)/textVertexSize))
+
+	gl.Disable(gl.BLEND)
+	t.vertices = t.vertices[:0]
+}
+
+func (t *Text) Delete() {
+	gl.DeleteTextures(1, &t.atlas)
+	gl.DeleteBuffers(1, &t.vbo)
+	gl.DeleteVertexArrays(1, &t.vao)
+	t.program.Delete()
+}
@@ -0,0 +1,53 @@
+package gl
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// UniformBuffer is a buffer object bound to a uniform block binding point.
+// Any program whose block is bound to the same point reads the same data.
+type UniformBuffer struct {
+	Handle  uint32
+	Binding uint32
+	size    int
+}
+
+func NewUniformBuffer(binding uint32) *UniformBuffer {
+	buffer := &UniformBuffer{Binding: binding}
+	gl.GenBuffers(1, &buffer.Handle)
+	return buffer
+}
+
+// Update replaces the contents of the buffer, reallocating it when the size
+// changes.
+func (b *UniformBuffer) Update(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.Handle)
+	if len(data) != b.size {
+		gl.BufferData(gl.UNIFORM_BUFFER, len(data), gl.Ptr(data), gl.DYNAMIC_DRAW)
+		gl.BindBufferBase(gl.UNIFORM_BUFFER, b.Binding, b.Handle)
+		b.size = len(data)
+	} else {
+		gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), gl.Ptr(data))
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+func (b *UniformBuffer) Delete() {
+	gl.DeleteBuffers(1, &b.Handle)
+}
+
+// BindUniformBlock connects the named uniform block to a binding point. It
+// returns false if the program does not use the block.
+func (prog *GLProgram) BindUniformBlock(name string, binding uint32) bool {
+	index := gl.GetUniformBlockIndex(prog.Handle, gl.Str(name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return false
+	}
+
+	gl.UniformBlockBinding(prog.Handle, index, binding)
+	return true
+}
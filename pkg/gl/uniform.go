@@ -0,0 +1,78 @@
+package gl
+
+import (
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ActiveUniform is a uniform that survived compilation and linking.
+type ActiveUniform struct {
+	Name     string
+	Location int32
+	Type     uint32
+	Size     int32
+}
+
+// ActiveUniforms lists the uniforms the linker kept. Array uniforms are
+// reported once under their base name.
+func (prog *GLProgram) ActiveUniforms() []ActiveUniform {
+	var count, maxLength int32
+	gl.GetProgramiv(prog.Handle, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(prog.Handle, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+
+	uniforms := make([]ActiveUniform, 0, count)
+	buf := make([]uint8, maxLength+1)
+	for i := uint32(0); i < uint32(count); i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(prog.Handle, i, int32(len(buf)), &length, &size, &xtype, &buf[0])
+
+		name := string(buf[:length])
+		location := gl.GetUniformLocation(prog.Handle, gl.Str(name+"\x00"))
+		if location < 0 {
+			// members of uniform blocks have no location
+			continue
+		}
+
+		uniforms = append(uniforms, ActiveUniform{
+			Name:     strings.TrimSuffix(name, "[0]"),
+			Location: location,
+			Type:     xtype,
+			Size:     size,
+		})
+	}
+
+	return uniforms
+}
+
+// TypeName returns the GLSL name of a uniform type.
+func TypeName(xtype uint32) string {
+	switch xtype {
+	case gl.FLOAT:
+		return "float"
+	case gl.FLOAT_VEC2:
+		return "vec2"
+	case gl.FLOAT_VEC3:
+		return "vec3"
+	case gl.FLOAT_VEC4:
+		return "vec4"
+	case gl.INT:
+		return "int"
+	case gl.INT_VEC2:
+		return "ivec2"
+	case gl.INT_VEC3:
+		return "ivec3"
+	case gl.INT_VEC4:
+		return "ivec4"
+	case gl.BOOL:
+		return "bool"
+	case gl.FLOAT_MAT3:
+		return "mat3"
+	case gl.FLOAT_MAT4:
+		return "mat4"
+	case gl.SAMPLER_2D:
+		return "sampler2D"
+	}
+	return "unknown"
+}
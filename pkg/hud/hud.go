@@ -0,0 +1,135 @@
+package hud
+
+import (
+	"fmt"
+
+	glprogram "remnant/pkg/gl"
+)
+
+// Anchor is the corner, edge or center of the window text is laid out from.
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+var White = [4]float32{1, 1, 1, 1}
+
+type line struct {
+	text  string
+	color [4]float32
+}
+
+// HUD is text drawn over the finished frame. Lines are printed every frame,
+// from scenes while they render and from the game, and stack away from the
+// anchor they are printed to. Draw shows them and starts over.
+//
+// A nil *HUD ignores everything, so callers need not check.
+type HUD struct {
+	Visible bool
+	// Scale multiplies the glyph size on top of the window's pixel ratio.
+	Scale  float32
+	Margin float32
+
+	text  *glprogram.Text
+	lines [BottomRight + 1][]line
+}
+
+func New(pp *glprogram.Preprocessor) (*HUD, error) {
+	text, err := glprogram.NewText(pp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HUD{
+		Visible: true,
+		Scale:   1,
+		Margin:  8,
+		text:    text,
+	}, nil
+}
+
+func (h *HUD) Print(anchor Anchor, text string) {
+	h.PrintColor(anchor, White, text)
+}
+
+func (h *HUD) Printf(anchor Anchor, format string, args ...interface{}) {
+	h.PrintColor(anchor, White, fmt.Sprintf(format, args...))
+}
+
+func (h *HUD) PrintColor(anchor Anchor, color [4]float32, text string) {
+	if h == nil {
+		return
+	}
+	h.lines[anchor] = append(h.lines[anchor], line{text, color})
+}
+
+// Draw lays out everything printed since the last Draw in a window
+// framebuffer of width by height and draws it into the bound framebuffer.
+func (h *HUD) Draw(width, height int, pixelRatio float64) {
+	if h == nil {
+		return
+	}
+
+	scale := h.Scale * float32(pixelRatio)
+	margin := h.Margin * float32(pixelRatio)
+
+	for anchor, lines := range h.lines {
+		if !h.Visible || len(lines) == 0 {
+			continue
+		}
+
+		column, row := anchor%3, anchor/3
+		var block float32
+		for _, l := range lines {
+			_, lh := glprogram.Measure(l.text, scale)
+			block += lh
+		}
+
+		var y float32
+		switch row {
+		case 0:
+			y = margin
+		case 1:
+			y = (float32(height) - block) / 2
+		case 2:
+			y = float32(height) - margin - block
+		}
+
+		for _, l := range lines {
+			w, lh := glprogram.Measure(l.text, scale)
+			var x float32
+			switch column {
+			case 0:
+				x = margin
+			case 1:
+				x = (float32(width) - w) / 2
+			case 2:
+				x = float32(width) - margin - w
+			}
+			h.text.Draw(x, y, scale, l.color, l.text)
+			y += lh
+		}
+	}
+	h.text.Flush(width, height)
+
+	for i := range h.lines {
+		h.lines[i] = h.lines[i][:0]
+	}
+}
+
+func (h *HUD) Stage(r *glprogram.Reload) error {
+	return h.text.Stage(r)
+}
+
+func (h *HUD) Delete() {
+	h.text.Delete()
+}
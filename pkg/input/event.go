@@ -0,0 +1,50 @@
+package input
+
+import "github.com/go-gl/glfw/v3.3/glfw"
+
+type EventKind int
+
+const (
+	KeyEvent EventKind = iota
+	MouseButtonEvent
+	CursorPosEvent
+	ScrollEvent
+	// CharEvent is text input, with the character in Char.
+	CharEvent
+)
+
+// Event is a window input callback packed into a value so it can be routed
+// to whichever scene is active.
+type Event struct {
+	Kind   EventKind
+	Window *glfw.Window
+
+	Key      glfw.Key
+	Scancode int
+	Button   glfw.MouseButton
+	Action   glfw.Action
+	Mods     glfw.ModifierKey
+
+	// X and Y hold the cursor position or the scroll offset.
+	X, Y float64
+	Char rune
+}
+
+// Forward installs window callbacks that pass every event to handler.
+func Forward(window *glfw.Window, handler func(Event)) {
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		handler(Event{Kind: KeyEvent, Window: w, Key: key, Scancode: scancode, Action: action, Mods: mods})
+	})
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		handler(Event{Kind: MouseButtonEvent, Window: w, Button: button, Action: action, Mods: mods})
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, xpos float64, ypos float64) {
+		handler(Event{Kind: CursorPosEvent, Window: w, X: xpos, Y: ypos})
+	})
+	window.SetScrollCallback(func(w *glfw.Window, xoff float64, yoff float64) {
+		handler(Event{Kind: ScrollEvent, Window: w, X: xoff, Y: yoff})
+	})
+	window.SetCharCallback(func(w *glfw.Window, char rune) {
+		handler(Event{Kind: CharEvent, Window: w, Char: char})
+	})
+}
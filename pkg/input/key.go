@@ -2,6 +2,15 @@ package input
 
 import "github.com/go-gl/glfw/v3.3/glfw"
 
+// captured is set while typed text owns the keyboard.
+var captured bool
+
+// Capture makes every Key read as released while on, so typing into the
+// console or a text field doesn't also fly the ship.
+func Capture(on bool) {
+	captured = on
+}
+
 type Key struct {
 	Pressed bool
 	Key     glfw.Key
@@ -16,6 +25,11 @@ func NewKey(key glfw.Key) *Key {
 }
 
 func (k *Key) UpdateKeyState(window *glfw.Window) bool {
+	if captured {
+		k.Pressed = false
+		return false
+	}
+
 	action := window.GetKey(k.Key)
 
 	if action == glfw.Press {
@@ -27,3 +41,9 @@ func (k *Key) UpdateKeyState(window *glfw.Window) bool {
 
 	return k.Pressed
 }
+
+// Triggered reports whether the key went down since the last check.
+func (k *Key) Triggered(window *glfw.Window) bool {
+	wasPressed := k.Pressed
+	return k.UpdateKeyState(window) && !wasPressed
+}
@@ -0,0 +1,107 @@
+package input
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// MouseLook turns cursor motion into yaw and pitch. Cursor callbacks only
+// accumulate deltas; Frame converts them once per frame so look speed does
+// not depend on window size or how often the OS delivers events.
+type MouseLook struct {
+	// Sensitivity is in radians per pixel of motion.
+	Sensitivity float64
+	InvertX     bool
+	InvertY     bool
+
+	// Acceleration scales look speed by 1 + Acceleration * (rate/1000)^Exponent
+	// where rate is the motion in pixels per second, capped at MaxGain.
+	Acceleration float64
+	Exponent     float64
+	MaxGain      float64
+
+	// Smoothing is the time constant in seconds of an exponential filter over
+	// the look rate. Zero disables smoothing.
+	Smoothing float64
+
+	lastX, lastY float64
+	hasLast      bool
+	dx, dy       float64
+	rateX, rateY float64
+}
+
+func NewMouseLook(sensitivity float64) *MouseLook {
+	return &MouseLook{
+		Sensitivity: sensitivity,
+		Exponent:    1,
+		MaxGain:     4,
+	}
+}
+
+// EnableRawMotion switches the window to unaccelerated raw mouse motion when
+// the platform supports it. The cursor must be disabled for it to apply.
+func EnableRawMotion(window *glfw.Window) bool {
+	if !glfw.RawMouseMotionSupported() {
+		return false
+	}
+	window.SetInputMode(glfw.RawMouseMotion, glfw.True)
+	return true
+}
+
+// Move records a cursor position, as delivered to a CursorPosCallback.
+func (m *MouseLook) Move(xpos, ypos float64) {
+	if m.hasLast {
+		m.dx += xpos - m.lastX
+		m.dy += ypos - m.lastY
+	}
+	m.lastX, m.lastY = xpos, ypos
+	m.hasLast = true
+}
+
+// Reset forgets the last cursor position and any pending motion, e.g. after
+// the cursor has been warped.
+func (m *MouseLook) Reset() {
+	m.hasLast = false
+	m.dx, m.dy = 0, 0
+	m.rateX, m.rateY = 0, 0
+}
+
+// Frame returns the yaw and pitch in radians accumulated since the previous
+// frame, dt seconds ago.
+func (m *MouseLook) Frame(dt float64) (yaw, pitch float64) {
+	dx, dy := m.dx, m.dy
+	m.dx, m.dy = 0, 0
+
+	if dt <= 0 {
+		return m.scale(dx, dy, 1)
+	}
+
+	rateX, rateY := dx/dt, dy/dt
+	if m.Smoothing > 0 {
+		alpha := 1 - math.Exp(-dt/m.Smoothing)
+		m.rateX += (rateX - m.rateX) * alpha
+		m.rateY += (rateY - m.rateY) * alpha
+		rateX, rateY = m.rateX, m.rateY
+	}
+
+	gain := 1.0
+	if m.Acceleration > 0 {
+		rate := math.Hypot(rateX, rateY)
+		gain = math.Min(1+m.Acceleration*math.Pow(rate/1000, m.Exponent), m.MaxGain)
+	}
+
+	return m.scale(rateX*dt, rateY*dt, gain)
+}
+
+func (m *MouseLook) scale(dx, dy, gain float64) (float64, float64) {
+	yaw := dx * m.Sensitivity * gain
+	pitch := dy * m.Sensitivity * gain
+	if m.InvertX {
+		yaw = -yaw
+	}
+	if m.InvertY {
+		pitch = -pitch
+	}
+	return yaw, pitch
+}
@@ -0,0 +1,102 @@
+package physics
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Drag opposes the body's velocity with a force of k1*|v| + k2*|v|^2.
+type Drag struct {
+	K1 float64 `ui:"0,1"`
+	K2 float64 `ui:"0,0.1"`
+}
+
+func NewLinearDrag(k float64) *Drag {
+	return &Drag{K1: k}
+}
+
+func NewQuadraticDrag(k float64) *Drag {
+	return &Drag{K2: k}
+}
+
+func (d *Drag) UpdateForce(rb *RigidBody, dt float64) {
+	speed := rb.Velocity.Norm(2)
+	if speed == 0 {
+		return
+	}
+
+	magnitude := d.K1*speed + d.K2*speed*speed
+	rb.Force.AddScaledVec(rb.Force, -magnitude/speed, rb.Velocity)
+}
+
+// Planet describes an exponential atmosphere around a sphere. Density is
+// SurfaceDensity at Radius and falls off by 1/e every ScaleHeight units.
+type Planet struct {
+	Center         *mat.VecDense
+	Radius         float64
+	SurfaceDensity float64
+	ScaleHeight    float64
+}
+
+func NewPlanet(center *mat.VecDense, radius, surfaceDensity, scaleHeight float64) *Planet {
+	return &Planet{
+		Center:         center,
+		Radius:         radius,
+		SurfaceDensity: surfaceDensity,
+		ScaleHeight:    scaleHeight,
+	}
+}
+
+func (p *Planet) Altitude(position *mat.VecDense) float64 {
+	d := mat.NewVecDense(3, nil)
+	d.SubVec(position, p.Center)
+	return d.Norm(2) - p.Radius
+}
+
+func (p *Planet) Density(position *mat.VecDense) float64 {
+	if p.ScaleHeight <= 0 {
+		return 0
+	}
+
+	altitude := math.Max(p.Altitude(position), 0)
+	return p.SurfaceDensity * math.Exp(-altitude/p.ScaleHeight)
+}
+
+// AtmosphericDrag applies quadratic drag scaled by the summed air density of
+// every planet at the body's position, so it vanishes in deep space.
+type AtmosphericDrag struct {
+	Planets []*Planet
+	// DragCoefficient folds the drag coefficient and reference area together.
+	DragCoefficient float64 `ui:"0,2"`
+}
+
+func NewAtmosphericDrag(dragCoefficient float64, planets ...*Planet) *AtmosphericDrag {
+	return &AtmosphericDrag{
+		Planets:         planets,
+		DragCoefficient: dragCoefficient,
+	}
+}
+
+func (a *AtmosphericDrag) Density(position *mat.VecDense) float64 {
+	density := 0.0
+	for _, p := range a.Planets {
+		density += p.Density(position)
+	}
+	return density
+}
+
+func (a *AtmosphericDrag) UpdateForce(rb *RigidBody, dt float64) {
+	speed := rb.Velocity.Norm(2)
+	if speed == 0 {
+		return
+	}
+
+	density := a.Density(rb.Position)
+	if density == 0 {
+		return
+	}
+
+	// F = -1/2 * rho * Cd * |v| * v
+	rb.Force.AddScaledVec(rb.Force, -0.5*density*a.DragCoefficient*speed, rb.Velocity)
+}
@@ -0,0 +1,26 @@
+package physics
+
+// ForceGenerator adds forces or torques to a rigid body every update,
+// before the body integrates its accumulated state.
+type ForceGenerator interface {
+	UpdateForce(rb *RigidBody, dt float64)
+}
+
+func (rb *RigidBody) AddForceGenerator(fg ForceGenerator) {
+	rb.Generators = append(rb.Generators, fg)
+}
+
+func (rb *RigidBody) RemoveForceGenerator(fg ForceGenerator) {
+	for i, g := range rb.Generators {
+		if g == fg {
+			rb.Generators = append(rb.Generators[:i], rb.Generators[i+1:]...)
+			return
+		}
+	}
+}
+
+func (rb *RigidBody) applyForceGenerators(dt float64) {
+	for _, fg := range rb.Generators {
+		fg.UpdateForce(rb, dt)
+	}
+}
@@ -2,6 +2,7 @@ package physics
 
 import (
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
 )
 
 type RigidBody struct {
@@ -9,11 +10,13 @@ type RigidBody struct {
 	Velocity     *mat.VecDense
 	Direction    *mat.VecDense
 	Rotation     *mat.VecDense
+	Orientation  quat.Number
 	AngularVel   *mat.VecDense
 	Acceleration *mat.VecDense
 	Force        *mat.VecDense
 	Torque       *mat.VecDense
 	Mass         float64
+	Generators   []ForceGenerator
 }
 
 func NewRigidBody(position *mat.VecDense) *RigidBody {
@@ -22,6 +25,7 @@ func NewRigidBody(position *mat.VecDense) *RigidBody {
 		Velocity:     mat.NewVecDense(3, []float64{0, 0, 0}),
 		Direction:    mat.NewVecDense(3, []float64{0, 0, 0}),
 		Rotation:     mat.NewVecDense(3, []float64{0, 0, 0}),
+		Orientation:  quat.Number{Real: 1},
 		Acceleration: mat.NewVecDense(3, []float64{0, 0, 0}),
 		AngularVel:   mat.NewVecDense(3, []float64{0, 0, 0}),
 		Force:        mat.NewVecDense(3, []float64{0, 0, 0}),
@@ -31,6 +35,7 @@ func NewRigidBody(position *mat.VecDense) *RigidBody {
 }
 
 func (rb *RigidBody) Update(dt float64) {
+	rb.applyForceGenerators(dt)
 
 	rb.Acceleration.ScaleVec(1/rb.Mass, rb.Force)
 	rb.Velocity.AddScaledVec(rb.Velocity, dt, rb.Acceleration)
@@ -38,6 +43,7 @@ func (rb *RigidBody) Update(dt float64) {
 
 	rb.AngularVel.AddScaledVec(rb.AngularVel, dt, rb.Torque)
 	rb.Direction.AddScaledVec(rb.Direction, dt, rb.AngularVel)
+	rb.integrateOrientation(dt)
 
 	rb.Force.ScaleVec(0, rb.Force)
 	rb.Torque.ScaleVec(0, rb.Torque)
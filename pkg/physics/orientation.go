@@ -0,0 +1,56 @@
+package physics
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+var (
+	localForward = mat.NewVecDense(3, []float64{0, 0, 1})
+	localUp      = mat.NewVecDense(3, []float64{0, 1, 0})
+	localRight   = mat.NewVecDense(3, []float64{1, 0, 0})
+)
+
+func (rb *RigidBody) Forward() *mat.VecDense {
+	return RotateVectorByQuaternion(localForward, rb.Orientation)
+}
+
+func (rb *RigidBody) Up() *mat.VecDense {
+	return RotateVectorByQuaternion(localUp, rb.Orientation)
+}
+
+func (rb *RigidBody) Right() *mat.VecDense {
+	return RotateVectorByQuaternion(localRight, rb.Orientation)
+}
+
+// Rotate turns the body about its own up, right and forward axes.
+func (rb *RigidBody) Rotate(yaw, pitch, roll float64) {
+	q := quat.Mul(CreateRotationQuaternion(yaw, localUp), CreateRotationQuaternion(pitch, localRight))
+	q = quat.Mul(q, CreateRotationQuaternion(roll, localForward))
+	rb.Orientation = NormalizeQuaternion(quat.Mul(rb.Orientation, q))
+}
+
+func (rb *RigidBody) integrateOrientation(dt float64) {
+	w := quat.Number{
+		Imag: rb.AngularVel.AtVec(0),
+		Jmag: rb.AngularVel.AtVec(1),
+		Kmag: rb.AngularVel.AtVec(2),
+	}
+	if w == (quat.Number{}) {
+		return
+	}
+
+	// dq/dt = 1/2 * w * q
+	dq := quat.Scale(0.5*dt, quat.Mul(w, rb.Orientation))
+	rb.Orientation = NormalizeQuaternion(quat.Add(rb.Orientation, dq))
+}
+
+func NormalizeQuaternion(q quat.Number) quat.Number {
+	n := quat.Abs(q)
+	if n == 0 || math.IsNaN(n) {
+		return quat.Number{Real: 1}
+	}
+	return quat.Scale(1/n, q)
+}
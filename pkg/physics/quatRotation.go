@@ -30,3 +30,67 @@ func RotateVectorByQuaternion(v *mat.VecDense, q quat.Number) *mat.VecDense {
 	// Extract the rotated vector
 	return mat.NewVecDense(3, []float64{rotatedQuat.Imag, rotatedQuat.Jmag, rotatedQuat.Kmag})
 }
+
+// QuaternionFromBasis returns the rotation taking local forward (0,0,1) and
+// up (0,1,0) onto the given directions. up is re-orthogonalised against
+// forward, so it only needs to be roughly perpendicular.
+func QuaternionFromBasis(forward, up *mat.VecDense) quat.Number {
+	f := normalize(forward)
+	r := normalize(Cross(up, f))
+	if r.Norm(2) == 0 {
+		return quat.Number{Real: 1}
+	}
+	u := Cross(f, r)
+
+	// rotation matrix with columns right, up, forward
+	m00, m01, m02 := r.AtVec(0), u.AtVec(0), f.AtVec(0)
+	m10, m11, m12 := r.AtVec(1), u.AtVec(1), f.AtVec(1)
+	m20, m21, m22 := r.AtVec(2), u.AtVec(2), f.AtVec(2)
+
+	var q quat.Number
+	trace := m00 + m11 + m22
+	switch {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q = quat.Number{Real: 0.25 / s, Imag: (m21 - m12) * s, Jmag: (m02 - m20) * s, Kmag: (m10 - m01) * s}
+	case m00 > m11 && m00 > m22:
+		s := 2 * math.Sqrt(1+m00-m11-m22)
+		q = quat.Number{Real: (m21 - m12) / s, Imag: 0.25 * s, Jmag: (m01 + m10) / s, Kmag: (m02 + m20) / s}
+	case m11 > m22:
+		s := 2 * math.Sqrt(1+m11-m00-m22)
+		q = quat.Number{Real: (m02 - m20) / s, Imag: (m01 + m10) / s, Jmag: 0.25 * s, Kmag: (m12 + m21) / s}
+	default:
+		s := 2 * math.Sqrt(1+m22-m00-m11)
+		q = quat.Number{Real: (m10 - m01) / s, Imag: (m02 + m20) / s, Jmag: (m12 + m21) / s, Kmag: 0.25 * s}
+	}
+	return NormalizeQuaternion(q)
+}
+
+func normalize(v *mat.VecDense) *mat.VecDense {
+	n := mat.NewVecDense(3, nil)
+	n.CopyVec(v)
+	if l := n.Norm(2); l > 0 {
+		n.ScaleVec(1/l, n)
+	}
+	return n
+}
+
+// Slerp interpolates between unit quaternions a and b along the shortest arc.
+func Slerp(a, b quat.Number, t float64) quat.Number {
+	dot := a.Real*b.Real + a.Imag*b.Imag + a.Jmag*b.Jmag + a.Kmag*b.Kmag
+	if dot < 0 {
+		b = quat.Scale(-1, b)
+		dot = -dot
+	}
+
+	// nearly identical rotations, lerp avoids dividing by sin(~0)
+	if dot > 0.9995 {
+		return NormalizeQuaternion(quat.Add(a, quat.Scale(t, quat.Sub(b, a))))
+	}
+
+	theta := math.Acos(dot)
+	sinTheta := math.Sin(theta)
+	wa := math.Sin((1-t)*theta) / sinTheta
+	wb := math.Sin(t*theta) / sinTheta
+	return NormalizeQuaternion(quat.Add(quat.Scale(wa, a), quat.Scale(wb, b)))
+}
@@ -0,0 +1,70 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// palette colors the overlay segments, in Breakdown order.
+var palette = [][3]float32{
+	{0.90, 0.30, 0.25},
+	{0.95, 0.65, 0.20},
+	{0.95, 0.90, 0.30},
+	{0.45, 0.80, 0.35},
+	{0.25, 0.70, 0.85},
+	{0.40, 0.45, 0.90},
+	{0.70, 0.40, 0.85},
+	{0.85, 0.85, 0.85},
+}
+
+// Color is the overlay color of the i-th entry of Breakdown.
+func Color(i int) [3]float32 {
+	return palette[i%len(palette)]
+}
+
+// DrawOverlay draws the breakdown into the bound framebuffer as two stacked
+// bars in the top left corner, CPU above GPU. The full bar width stands for
+// budget. Only scissored clears are used, so no shader state is touched.
+func (p *Profiler) DrawOverlay(width, height int, budget time.Duration) {
+	if p == nil || budget <= 0 {
+		return
+	}
+
+	var clear [4]float32
+	gl.GetFloatv(gl.COLOR_CLEAR_VALUE, &clear[0])
+	gl.Enable(gl.SCISSOR_TEST)
+
+	const margin, barHeight = 8, 10
+	barWidth := width / 3
+	rows := map[string]int{CPU: 0, GPU: 1}
+	offsets := map[string]int{}
+
+	for _, row := range []int{0, 1} {
+		y := height - margin - (row+1)*barHeight - row*4
+		fill(margin, y, barWidth, barHeight, [3]float32{0.1, 0.1, 0.1})
+	}
+
+	for i, t := range p.Breakdown() {
+		row := rows[t.Category]
+		w := int(float64(barWidth) * float64(t.Average) / float64(budget))
+		x := margin + offsets[t.Category]
+		if x+w > margin+barWidth {
+			w = margin + barWidth - x
+		}
+		if w > 0 {
+			y := height - margin - (row+1)*barHeight - row*4
+			fill(x, y, w, barHeight, Color(i))
+		}
+		offsets[t.Category] += w
+	}
+
+	gl.Disable(gl.SCISSOR_TEST)
+	gl.ClearColor(clear[0], clear[1], clear[2], clear[3])
+}
+
+func fill(x, y, w, h int, color [3]float32) {
+	gl.Scissor(int32(x), int32(y), int32(w), int32(h))
+	gl.ClearColor(color[0], color[1], color[2], 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+}
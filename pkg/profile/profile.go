@@ -0,0 +1,288 @@
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// frameLatency is how many frames of GPU queries are in flight. Results are
+// read back that many frames later, by when the GPU has long finished, so
+// reading them never stalls.
+const frameLatency = 3
+
+const (
+	CPU = "cpu"
+	GPU = "gpu"
+)
+
+// Event is one timed scope. Start is relative to when the profiler was
+// created. GPU events are placed at the time they were submitted.
+type Event struct {
+	Name     string
+	Category string
+	Frame    int
+	Start    time.Duration
+	Duration time.Duration
+	Depth    int
+}
+
+// Timing is the rolling average of a scope per frame.
+type Timing struct {
+	Name     string
+	Category string
+	Average  time.Duration
+}
+
+type query struct {
+	id     uint32
+	name   string
+	start  time.Duration
+	frame  int
+	issued bool
+}
+
+type slot struct {
+	queries []*query
+	used    int
+}
+
+type openScope struct {
+	name  string
+	start time.Duration
+}
+
+// Profiler times CPU scopes with Go timers and GPU passes with
+// GL_TIME_ELAPSED queries and keeps a per frame breakdown. A nil or disabled
+// Profiler does nothing, so callers can instrument unconditionally.
+//
+// GPU scopes cannot nest, the GL allows one elapsed time query at a time.
+type Profiler struct {
+	Enabled bool
+	// Trace keeps every event for SaveTrace.
+	Trace bool
+
+	origin time.Time
+	frame  int
+	slots  [frameLatency]slot
+	open   *query
+	stack  []openScope
+
+	current  map[string]time.Duration
+	averages map[string]*Timing
+	events   []Event
+}
+
+func New() *Profiler {
+	return &Profiler{
+		origin:   time.Now(),
+		current:  map[string]time.Duration{},
+		averages: map[string]*Timing{},
+	}
+}
+
+func (p *Profiler) now() time.Duration {
+	return time.Since(p.origin)
+}
+
+// BeginFrame collects the GPU results of the frame whose queries are about
+// to be reused.
+func (p *Profiler) BeginFrame() {
+	if p == nil || !p.Enabled {
+		return
+	}
+
+	s := &p.slots[p.frame%frameLatency]
+	for _, q := range s.queries[:s.used] {
+		if !q.issued {
+			continue
+		}
+		q.issued = false
+
+		var available int32
+		gl.GetQueryObjectiv(q.id, gl.QUERY_RESULT_AVAILABLE, &available)
+		if available == 0 {
+			// too slow to come back, drop it rather than wait
+			continue
+		}
+
+		var ns uint64
+		gl.GetQueryObjectui64v(q.id, gl.QUERY_RESULT, &ns)
+		p.record(Event{Name: q.name, Category: GPU, Frame: q.frame, Start: q.start, Duration: time.Duration(ns)})
+	}
+	s.used = 0
+	p.flush(GPU)
+}
+
+// EndFrame folds the CPU scopes of this frame into the averages.
+func (p *Profiler) EndFrame() {
+	if p == nil || !p.Enabled {
+		return
+	}
+	p.flush(CPU)
+	p.frame++
+}
+
+// CPU starts timing a scope and returns the function that ends it.
+//
+//	defer profiler.CPU("update")()
+func (p *Profiler) CPU(name string) func() {
+	if p == nil || !p.Enabled {
+		return noop
+	}
+
+	p.stack = append(p.stack, openScope{name: name, start: p.now()})
+	return func() {
+		top := p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+		p.record(Event{Name: top.name, Category: CPU, Frame: p.frame, Start: top.start, Duration: p.now() - top.start, Depth: len(p.stack)})
+	}
+}
+
+// GPU wraps the GL commands issued until the returned function is called in
+// an elapsed time query. Starting a GPU scope ends any that is still open.
+func (p *Profiler) GPU(name string) func() {
+	if p == nil || !p.Enabled {
+		return noop
+	}
+	if p.open != nil {
+		p.endGPU()
+	}
+
+	s := &p.slots[p.frame%frameLatency]
+	if s.used == len(s.queries) {
+		q := &query{}
+		gl.GenQueries(1, &q.id)
+		s.queries = append(s.queries, q)
+	}
+	q := s.queries[s.used]
+	s.used++
+
+	q.name, q.start, q.frame, q.issued = name, p.now(), p.frame, true
+	gl.BeginQuery(gl.TIME_ELAPSED, q.id)
+	p.open = q
+
+	return func() {
+		if p.open == q {
+			p.endGPU()
+		}
+	}
+}
+
+func (p *Profiler) endGPU() {
+	gl.EndQuery(gl.TIME_ELAPSED)
+	p.open = nil
+}
+
+func (p *Profiler) record(e Event) {
+	if e.Depth == 0 {
+		p.current[e.Category+":"+e.Name] += e.Duration
+	}
+	if p.Trace {
+		p.events = append(p.events, e)
+	}
+}
+
+// flush turns the summed scopes of one frame into rolling averages. Scopes
+// that did not run this frame count as zero.
+func (p *Profiler) flush(category string) {
+	prefix := category + ":"
+	for key, d := range p.current {
+		if _, ok := p.averages[key]; !ok && strings.HasPrefix(key, prefix) {
+			p.averages[key] = &Timing{Name: strings.TrimPrefix(key, prefix), Category: category, Average: d}
+		}
+	}
+
+	for key, t := range p.averages {
+		if t.Category != category {
+			continue
+		}
+		t.Average += (p.current[key] - t.Average) / 20
+		delete(p.current, key)
+	}
+}
+
+// Breakdown returns the average time per frame of every top level scope,
+// CPU scopes first, each sorted by name.
+func (p *Profiler) Breakdown() []Timing {
+	if p == nil {
+		return nil
+	}
+
+	timings := make([]Timing, 0, len(p.averages))
+	for _, t := range p.averages {
+		timings = append(timings, *t)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Category != timings[j].Category {
+			return timings[i].Category == CPU
+		}
+		return timings[i].Name < timings[j].Name
+	})
+	return timings
+}
+
+type traceEvent struct {
+	Name     string                 `json:"name"`
+	Category string                 `json:"cat"`
+	Phase    string                 `json:"ph"`
+	Time     float64                `json:"ts"`
+	Duration float64                `json:"dur"`
+	PID      int                    `json:"pid"`
+	TID      int                    `json:"tid"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+}
+
+// SaveTrace writes the recorded events as Chrome trace-event JSON, which
+// chrome://tracing and Perfetto open. CPU and GPU are separate threads.
+func (p *Profiler) SaveTrace(file string) error {
+	events := make([]traceEvent, 0, len(p.events)+2)
+	for tid, name := range []string{CPU, GPU} {
+		events = append(events, traceEvent{Name: "thread_name", Phase: "M", PID: 1, TID: tid + 1, Args: map[string]interface{}{"name": name}})
+	}
+
+	for _, e := range p.events {
+		tid := 1
+		if e.Category == GPU {
+			tid = 2
+		}
+		events = append(events, traceEvent{
+			Name:     e.Name,
+			Category: e.Category,
+			Phase:    "X",
+			Time:     float64(e.Start) / float64(time.Microsecond),
+			Duration: float64(e.Duration) / float64(time.Microsecond),
+			PID:      1,
+			TID:      tid,
+			Args:     map[string]interface{}{"frame": e.Frame},
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"traceEvents": events, "displayTimeUnit": "ms"})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// Delete frees the query objects.
+func (p *Profiler) Delete() {
+	if p == nil {
+		return
+	}
+	if p.open != nil {
+		p.endGPU()
+	}
+	for i := range p.slots {
+		for _, q := range p.slots[i].queries {
+			gl.DeleteQueries(1, &q.id)
+		}
+		p.slots[i].queries = nil
+	}
+}
+
+func noop() {}
@@ -0,0 +1,149 @@
+package program
+
+import (
+	glprogram "remnant/pkg/gl"
+)
+
+// Uniform block binding points, matching shaders/lib/frame.glsl.
+const (
+	FrameBinding = iota
+	CameraBinding
+	LightBinding
+	PreviousCameraBinding
+)
+
+// FrameData, CameraData and LightData mirror the std140 uniform blocks of the
+// same names. PreviousCameraData is a CameraData.
+type FrameData struct {
+	Time       float32
+	Resolution [2]float32
+	Jitter     [2]float32
+	FrameIndex int32
+	Temporal   bool
+}
+
+type CameraData struct {
+	Position  [3]float32
+	FOV       float32
+	Direction [3]float32
+	Up        [3]float32
+}
+
+type LightData struct {
+	Position [3]float32
+}
+
+// UniformBlock keeps a Go struct in sync with a uniform buffer. Changes to
+// Data are uploaded on the next Upload after Invalidate.
+type UniformBlock struct {
+	Name string
+	Data interface{}
+
+	buffer  *glprogram.UniformBuffer
+	encoded []byte
+	dirty   bool
+}
+
+func NewUniformBlock(name string, binding uint32, data interface{}) (*UniformBlock, error) {
+	// encode once up front so layout errors surface here and not mid-frame
+	encoded, err := glprogram.AppendStd140(nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UniformBlock{
+		Name:    name,
+		Data:    data,
+		buffer:  glprogram.NewUniformBuffer(binding),
+		encoded: encoded,
+		dirty:   true,
+	}, nil
+}
+
+func (b *UniformBlock) Invalidate() {
+	b.dirty = true
+}
+
+func (b *UniformBlock) Upload() {
+	if !b.dirty {
+		return
+	}
+
+	b.encoded, _ = glprogram.AppendStd140(b.encoded[:0], b.Data)
+	b.buffer.Update(b.encoded)
+	b.dirty = false
+}
+
+// Bind connects the block of a program to this buffer's binding point.
+func (b *UniformBlock) Bind(program *glprogram.GLProgram) bool {
+	return program.BindUniformBlock(b.Name, b.buffer.Binding)
+}
+
+func (b *UniformBlock) Delete() {
+	b.buffer.Delete()
+}
+
+// FrameBlocks holds the per-frame uniform blocks. One set is shared by every
+// shader program that includes frame.glsl.
+type FrameBlocks struct {
+	Frame    FrameData
+	Camera   CameraData
+	Light    LightData
+	Previous CameraData
+
+	frame    *UniformBlock
+	camera   *UniformBlock
+	light    *UniformBlock
+	previous *UniformBlock
+}
+
+func NewFrameBlocks() (*FrameBlocks, error) {
+	f := &FrameBlocks{}
+
+	var err error
+	if f.frame, err = NewUniformBlock("FrameData", FrameBinding, &f.Frame); err != nil {
+		return nil, err
+	}
+	if f.camera, err = NewUniformBlock("CameraData", CameraBinding, &f.Camera); err != nil {
+		f.frame.Delete()
+		return nil, err
+	}
+	if f.light, err = NewUniformBlock("LightData", LightBinding, &f.Light); err != nil {
+		f.camera.Delete()
+		f.frame.Delete()
+		return nil, err
+	}
+	if f.previous, err = NewUniformBlock("PreviousCameraData", PreviousCameraBinding, &f.Previous); err != nil {
+		f.light.Delete()
+		f.camera.Delete()
+		f.frame.Delete()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *FrameBlocks) blocks() []*UniformBlock {
+	return []*UniformBlock{f.frame, f.camera, f.light, f.previous}
+}
+
+// Bind connects a program's blocks to the shared buffers. Blocks the program
+// does not declare are skipped.
+func (f *FrameBlocks) Bind(program *glprogram.GLProgram) {
+	for _, block := range f.blocks() {
+		block.Bind(program)
+	}
+}
+
+// Upload sends the blocks changed since the last upload to the GPU.
+func (f *FrameBlocks) Upload() {
+	for _, block := range f.blocks() {
+		block.Upload()
+	}
+}
+
+func (f *FrameBlocks) Delete() {
+	for _, block := range f.blocks() {
+		block.Delete()
+	}
+}
@@ -1,47 +1,116 @@
 package program
 
 import (
+	"math"
 	"remnant/pkg/physics"
 
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/num/quat"
 )
 
+var (
+	cameraForward = mat.NewVecDense(3, []float64{0, 0, 1})
+	cameraUp      = mat.NewVecDense(3, []float64{0, 1, 0})
+	cameraRight   = mat.NewVecDense(3, []float64{1, 0, 0})
+)
+
+// Camera stores its rotation as a single unit quaternion so the derived
+// Dir, Up and Right vectors stay orthonormal however long it is rotated.
 type Camera struct {
-	Pos *mat.VecDense
-	Dir *mat.VecDense
-	Up  *mat.VecDense
-	FOV float32
+	Pos         *mat.VecDense
+	Orientation quat.Number
+	FOV         float32
+
+	// ClampPitch switches Rotate to FPS-style look: yaw turns about WorldUp
+	// and pitch stops MaxPitch radians short of straight up or down.
+	ClampPitch bool
+	MaxPitch   float64
+	WorldUp    *mat.VecDense
 }
 
 func NewCamera(positon *mat.VecDense, fov float32) *Camera {
 	return &Camera{
-		Pos: positon,
-		Dir: mat.NewVecDense(3, []float64{0, 0, 1}),
-		Up:  mat.NewVecDense(3, []float64{0, 1, 0}),
-		FOV: fov,
+		Pos:         positon,
+		Orientation: quat.Number{Real: 1},
+		FOV:         fov,
+		MaxPitch:    math.Pi/2 - 0.01,
+		WorldUp:     mat.NewVecDense(3, []float64{0, 1, 0}),
 	}
 }
 
-func (c *Camera) Rotate(xRad, yRad float64) {
+func (c *Camera) Dir() *mat.VecDense {
+	return physics.RotateVectorByQuaternion(cameraForward, c.Orientation)
+}
 
-	qx := physics.CreateRotationQuaternion(xRad, c.Up)
-	qy := physics.CreateRotationQuaternion(yRad, physics.Cross(c.Up, c.Dir))
+func (c *Camera) Up() *mat.VecDense {
+	return physics.RotateVectorByQuaternion(cameraUp, c.Orientation)
+}
 
-	combinedRotation := quat.Mul(qx, qy)
-	rotatedVec := physics.RotateVectorByQuaternion(c.Dir, combinedRotation)
-	rotatedUp := physics.RotateVectorByQuaternion(c.Up, combinedRotation)
+func (c *Camera) Right() *mat.VecDense {
+	return physics.RotateVectorByQuaternion(cameraRight, c.Orientation)
+}
 
-	c.Dir = rotatedVec
-	c.Up = rotatedUp
+// Rotate yaws by xRad and pitches by yRad about the camera's own axes, or
+// about WorldUp with a clamped pitch when ClampPitch is set.
+func (c *Camera) Rotate(xRad, yRad float64) {
+	if c.ClampPitch {
+		c.rotateClamped(xRad, yRad)
+		return
+	}
+	c.rotateLocal(physics.CreateRotationQuaternion(xRad, cameraUp))
+	c.rotateLocal(physics.CreateRotationQuaternion(yRad, cameraRight))
 }
 
 func (c *Camera) RotateZ(xRad float64) {
-	qz := physics.CreateRotationQuaternion(xRad, c.Dir)
+	c.Roll(xRad)
+}
 
-	rotatedVec := physics.RotateVectorByQuaternion(c.Dir, qz)
-	rotatedUp := physics.RotateVectorByQuaternion(c.Up, qz)
+func (c *Camera) Yaw(rad float64) {
+	c.rotateLocal(physics.CreateRotationQuaternion(rad, cameraUp))
+}
 
-	c.Dir = rotatedVec
-	c.Up = rotatedUp
+func (c *Camera) Pitch(rad float64) {
+	c.rotateLocal(physics.CreateRotationQuaternion(rad, cameraRight))
+}
+
+func (c *Camera) Roll(rad float64) {
+	c.rotateLocal(physics.CreateRotationQuaternion(rad, cameraForward))
+}
+
+// SetBasis points the camera along dir with up as close to up as possible.
+func (c *Camera) SetBasis(dir, up *mat.VecDense) {
+	c.Orientation = physics.QuaternionFromBasis(dir, up)
+}
+
+// LookAt turns the camera towards target, keeping WorldUp as up.
+func (c *Camera) LookAt(target *mat.VecDense) {
+	dir := mat.NewVecDense(3, nil)
+	dir.SubVec(target, c.Pos)
+	if dir.Norm(2) == 0 {
+		return
+	}
+	c.SetBasis(dir, c.worldUp())
+}
+
+func (c *Camera) rotateLocal(q quat.Number) {
+	c.Orientation = physics.NormalizeQuaternion(quat.Mul(c.Orientation, q))
+}
+
+func (c *Camera) rotateClamped(yaw, pitch float64) {
+	up := c.worldUp()
+	current := math.Asin(math.Max(-1, math.Min(1, mat.Dot(c.Dir(), up))))
+
+	// pitch is measured upwards, rotating about right tips the view down
+	target := math.Max(-c.MaxPitch, math.Min(c.MaxPitch, current-pitch))
+
+	qYaw := physics.CreateRotationQuaternion(yaw, up)
+	c.Orientation = physics.NormalizeQuaternion(quat.Mul(qYaw, c.Orientation))
+	c.rotateLocal(physics.CreateRotationQuaternion(current-target, cameraRight))
+}
+
+func (c *Camera) worldUp() *mat.VecDense {
+	if c.WorldUp == nil {
+		return cameraUp
+	}
+	return c.WorldUp
 }
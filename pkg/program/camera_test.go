@@ -0,0 +1,111 @@
+package program
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	rotationSteps  = 10000
+	basisTolerance = 1e-9
+)
+
+// checkBasis fails if Dir, Up and Right are not unit length and mutually
+// orthogonal.
+func checkBasis(t *testing.T, c *Camera, step int) {
+	t.Helper()
+
+	dir, up, right := c.Dir(), c.Up(), c.Right()
+	for _, v := range []struct {
+		name string
+		vec  *mat.VecDense
+	}{{"dir", dir}, {"up", up}, {"right", right}} {
+		if n := v.vec.Norm(2); math.Abs(n-1) > basisTolerance {
+			t.Fatalf("step %d: |%s| = %v", step, v.name, n)
+		}
+	}
+
+	for _, pair := range []struct {
+		name string
+		a, b *mat.VecDense
+	}{{"dir.up", dir, up}, {"dir.right", dir, right}, {"up.right", up, right}} {
+		if d := mat.Dot(pair.a, pair.b); math.Abs(d) > basisTolerance {
+			t.Fatalf("step %d: %s = %v", step, pair.name, d)
+		}
+	}
+}
+
+func randomAngle(r *rand.Rand) float64 {
+	return (r.Float64()*2 - 1) * math.Pi
+}
+
+func randomPoint(r *rand.Rand) *mat.VecDense {
+	return mat.NewVecDense(3, []float64{
+		r.Float64()*200 - 100,
+		r.Float64()*200 - 100,
+		r.Float64()*200 - 100,
+	})
+}
+
+func TestCameraStaysOrthonormal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	c := NewCamera(mat.NewVecDense(3, nil), 60)
+
+	for i := 0; i < rotationSteps; i++ {
+		switch r.Intn(6) {
+		case 0:
+			c.Yaw(randomAngle(r))
+		case 1:
+			c.Pitch(randomAngle(r))
+		case 2:
+			c.Roll(randomAngle(r))
+		case 3:
+			c.Rotate(randomAngle(r), randomAngle(r))
+		case 4:
+			c.RotateZ(randomAngle(r))
+		case 5:
+			c.Pos = randomPoint(r)
+			c.LookAt(randomPoint(r))
+		}
+		checkBasis(t, c, i)
+	}
+}
+
+func TestCameraSmallRotationsDoNotDrift(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	c := NewCamera(mat.NewVecDense(3, nil), 60)
+
+	// mouse look sized steps, where error used to accumulate
+	for i := 0; i < rotationSteps; i++ {
+		c.Rotate(randomAngle(r)*0.01, randomAngle(r)*0.01)
+		c.RotateZ(randomAngle(r) * 0.01)
+		checkBasis(t, c, i)
+	}
+}
+
+func TestCameraClampsPitch(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	c := NewCamera(mat.NewVecDense(3, nil), 60)
+	c.ClampPitch = true
+
+	for i := 0; i < rotationSteps; i++ {
+		// LookAt may point anywhere, the next Rotate brings it back in range
+		if r.Intn(10) == 0 {
+			c.Pos = randomPoint(r)
+			c.LookAt(randomPoint(r))
+			checkBasis(t, c, i)
+			continue
+		}
+
+		c.Rotate(randomAngle(r), randomAngle(r))
+		checkBasis(t, c, i)
+
+		pitch := math.Asin(math.Max(-1, math.Min(1, mat.Dot(c.Dir(), c.WorldUp))))
+		if math.Abs(pitch) > c.MaxPitch+basisTolerance {
+			t.Fatalf("step %d: pitch %v beyond %v", i, pitch, c.MaxPitch)
+		}
+	}
+}
@@ -2,35 +2,56 @@ package program
 
 import (
 	glprogram "remnant/pkg/gl"
+	"remnant/pkg/hud"
+	"remnant/pkg/profile"
+	"remnant/pkg/sdf"
+	"remnant/pkg/ui"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"gonum.org/v1/gonum/mat"
 )
 
 const (
-	TIME_UNFOIRM_NAME       = "time\x00"
-	CAMERA_POS_UNIFORM_NAME = "camera_position\x00"
-	CAMERA_DIR_UNIFORM_NAME = "camera_direction\x00"
-	CAMERA_UP_UNIFORM_NAME  = "camera_up\x00"
-	CAMERA_FOV_UNIFORM_NAME = "camera_fov\x00"
-	LIGHT_POS_UNIFORM_NAME  = "light\x00"
-	RESOLUTION_UNIFORM_NAME = "resolution\x00"
-	DATA_UNIFORM_NAME       = "tex\x00"
+	DATA_UNIFORM_NAME    = "tex"
+	OBJECT_COUNT_UNIFORM = "object_count"
+	DEBUG_MODE_UNIFORM   = "debug_mode"
+)
+
+// Shader #defines injected by default. MaxObjects bounds how many objects a
+// scene may put in the data texture.
+const (
+	MaxObjects      = 64
+	DefaultMaxSteps = 128
+	DefaultQuality  = 1
 )
 
 type Program struct {
-	GLProgram *glprogram.GLProgram
-	Window    *glfw.Window
+	GLProgram    *glprogram.GLProgram
+	Window       *glfw.Window
+	Preprocessor *glprogram.Preprocessor
+	Uniforms     *Uniforms
+	Blocks       *FrameBlocks
 
-	VAO        uint32
-	time       int32
-	cameraPos  int32
-	cameraDir  int32
-	cameraUp   int32
-	cameraFOV  int32
-	lightPos   int32
-	resolution int32
-	texture    int32
+	// Target is rendered at the scaled resolution and upscaled to the window
+	Target   *glprogram.Framebuffer
+	Scaler   *ResolutionScaler
+	Post     *glprogram.PostChain
+	Temporal *Temporal
+	Preset   Preset
+	// HUD is text scenes print while they render, UI the debug panels
+	// they declare. Both are drawn by DrawOverlays.
+	HUD *hud.HUD
+	UI  *ui.Context
+	// Profiler times the GPU passes, it may be nil
+	Profiler *profile.Profiler
+	// Debug replaces the shaded image with a diagnostic view, shown without
+	// temporal accumulation or post-processing
+	Debug sdf.DebugMode
+
+	VAO    uint32
+	width  int
+	height int
 }
 
 var vertices = []float32{
@@ -44,28 +65,254 @@ var vertices = []float32{
 	-1.0, 1.0, 0.0, 0.0, 1.0, // Top Left
 }
 
-func NewProgram(windows *glfw.Window) *Program {
-	program, err := glprogram.CreateGLProgram()
+func NewProgram(windows *glfw.Window) (*Program, error) {
+	pp := glprogram.NewPreprocessor("shaders/lib")
+	pp.Define("OBJ_COUNT", MaxObjects)
+	pp.Define("MAX_STEPS", DefaultMaxSteps)
+	pp.Define("QUALITY", DefaultQuality)
+
+	program, err := glprogram.CreateGLProgram(pp)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := NewFrameBlocks()
+	if err != nil {
+		program.Delete()
+		return nil, err
+	}
+
+	width, height := windows.GetFramebufferSize()
+	target, err := glprogram.NewFramebuffer(width, height)
 	if err != nil {
-		panic(err)
+		blocks.Delete()
+		program.Delete()
+		return nil, err
+	}
+
+	post, err := glprogram.NewPostChain(pp)
+	if err != nil {
+		target.Delete()
+		blocks.Delete()
+		program.Delete()
+		return nil, err
+	}
+
+	temporal, err := NewTemporal(pp)
+	if err != nil {
+		post.Delete()
+		target.Delete()
+		blocks.Delete()
+		program.Delete()
+		return nil, err
+	}
+	blocks.Bind(temporal.Program())
+
+	overlay, err := hud.New(pp)
+	if err != nil {
+		temporal.Delete()
+		post.Delete()
+		target.Delete()
+		blocks.Delete()
+		program.Delete()
+		return nil, err
+	}
+
+	panels, err := ui.New(pp)
+	if err != nil {
+		overlay.Delete()
+		temporal.Delete()
+		post.Delete()
+		target.Delete()
+		blocks.Delete()
+		program.Delete()
+		return nil, err
 	}
 
 	s := &Program{
-		GLProgram:  program,
-		Window:     windows,
-		VAO:        createTriangleVAO(vertices),
-		time:       gl.GetUniformLocation(program.Handle, gl.Str(TIME_UNFOIRM_NAME)),
-		cameraPos:  gl.GetUniformLocation(program.Handle, gl.Str(CAMERA_POS_UNIFORM_NAME)),
-		cameraDir:  gl.GetUniformLocation(program.Handle, gl.Str(CAMERA_DIR_UNIFORM_NAME)),
-		cameraUp:   gl.GetUniformLocation(program.Handle, gl.Str(CAMERA_UP_UNIFORM_NAME)),
-		cameraFOV:  gl.GetUniformLocation(program.Handle, gl.Str(CAMERA_FOV_UNIFORM_NAME)),
-		lightPos:   gl.GetUniformLocation(program.Handle, gl.Str(LIGHT_POS_UNIFORM_NAME)),
-		resolution: gl.GetUniformLocation(program.Handle, gl.Str(RESOLUTION_UNIFORM_NAME)),
-		texture:    gl.GetUniformLocation(program.Handle, gl.Str(DATA_UNIFORM_NAME)),
+		Window:       windows,
+		Preprocessor: pp,
+		Uniforms:     NewUniforms(),
+		Blocks:       blocks,
+		Target:       target,
+		Post:         post,
+		Temporal:     temporal,
+		HUD:          overlay,
+		UI:           panels,
+		Scaler:       NewResolutionScaler(60),
+		Preset:       Presets[2],
+		VAO:          createTriangleVAO(vertices),
 	}
+	s.use(program)
+
+	return s, nil
+}
 
+// SetDefine changes a shader #define. It takes effect on the next Reload.
+func (s *Program) SetDefine(name string, value interface{}) {
+	s.Preprocessor.Define(name, value)
+}
+
+// Reload recompiles the shaders from disk, post-processing, overlays and
+// anything in extra included. Nothing is replaced unless every program
+// compiles and links; otherwise the current ones keep running and the error
+// is returned.
+func (s *Program) Reload(extra ...glprogram.Stager) error {
+	stagers := append([]glprogram.Stager{s, s.Temporal, s.HUD, s.UI, s.Post}, extra...)
+	if err := glprogram.Apply(s.Preprocessor, stagers...); err != nil {
+		return err
+	}
+	s.Blocks.Bind(s.Temporal.Program())
+	return nil
+}
+
+// Stage recompiles the ray marching program as part of r.
+func (s *Program) Stage(r *glprogram.Reload) error {
+	return r.Compile("shaders/vertex.glsl", "shaders/fragment.glsl", func(program *glprogram.GLProgram) {
+		s.GLProgram.Delete()
+		s.use(program)
+	})
+}
+
+// ApplyPreset switches to fixed-scale rendering with the preset's quality
+// settings. The shaders are recompiled if the defines change; if that fails
+// the previous preset stays in effect.
+func (s *Program) ApplyPreset(preset Preset) error {
+	if preset.MaxSteps != s.Preset.MaxSteps || preset.Quality != s.Preset.Quality {
+		s.SetDefine("MAX_STEPS", preset.MaxSteps)
+		s.SetDefine("QUALITY", preset.Quality)
+		if err := s.Reload(); err != nil {
+			s.SetDefine("MAX_STEPS", s.Preset.MaxSteps)
+			s.SetDefine("QUALITY", s.Preset.Quality)
+			return err
+		}
+	}
+
+	s.Scaler.Auto = false
+	s.Scaler.Scale = preset.Scale
+	s.Preset = preset
+	return nil
+}
+
+func (s *Program) use(program *glprogram.GLProgram) {
+	s.GLProgram = program
 	program.Use()
-	return s
+	s.Uniforms.bind(program)
+	s.Blocks.Bind(program)
+}
+
+// RegisterUniform sets a custom uniform from source before every draw.
+func (s *Program) RegisterUniform(name string, source UniformSource) {
+	s.Uniforms.Register(name, source)
+}
+
+func (s *Program) UnregisterUniform(name string) {
+	s.Uniforms.Unregister(name)
+}
+
+func (s *Program) SetFloat(name string, v float32) {
+	s.Uniforms.set(name, floatValue(v))
+}
+
+func (s *Program) SetInt(name string, v int) {
+	s.Uniforms.set(name, uniformValue{xtype: gl.INT, i: int32(v)})
+}
+
+func (s *Program) SetVec2(name string, x, y float32) {
+	s.Uniforms.set(name, uniformValue{xtype: gl.FLOAT_VEC2, f: [4]float32{x, y}})
+}
+
+func (s *Program) SetVec3(name string, v *mat.VecDense) {
+	s.setVec(name, v)
+}
+
+func (s *Program) SetVec4(name string, v *mat.VecDense) {
+	s.setVec(name, v)
+}
+
+func (s *Program) setVec(name string, v *mat.VecDense) {
+	value, ok := vecValue(v)
+	if !ok {
+		s.Uniforms.warn(name, "uniform %q set from a vector of length %d", name, v.Len())
+		return
+	}
+	s.Uniforms.set(name, value)
+}
+
+// Begin starts a frame for a window framebuffer of width by height, rendering
+// into the offscreen target at the current render scale.
+func (s *Program) Begin(width, height int) error {
+	s.width, s.height = width, height
+
+	w, h := s.Scaler.Size(width, height)
+	if err := s.Target.Resize(w, h); err != nil {
+		return err
+	}
+
+	// the camera set during the last frame becomes the previous camera
+	frame := &s.Blocks.Frame
+	frame.Jitter = [2]float32{}
+	frame.Temporal = s.Temporal.Enabled && s.Debug == sdf.DebugOff
+	if frame.Temporal {
+		frame.Jitter = s.Temporal.Jitter()
+	}
+	frame.FrameIndex++
+	s.Blocks.Previous = s.Blocks.Camera
+	s.Blocks.previous.Invalidate()
+
+	s.Target.Bind()
+	s.Clear()
+	s.SetResolution(w, h)
+	return nil
+}
+
+// End resolves the frame against the temporal history, runs the
+// post-processing chain and upscales the result to the window.
+func (s *Program) End() error {
+	// blending is for drawing into the target, not for post or the blit
+	gl.Disable(gl.BLEND)
+	s.Blocks.Upload()
+
+	if s.Debug != sdf.DebugOff {
+		// the history goes stale while it isn't accumulated
+		s.Temporal.Invalidate()
+		end := s.Profiler.GPU("blit")
+		s.Target.BlitToScreen(s.width, s.height)
+		end()
+		return nil
+	}
+
+	end := s.Profiler.GPU("taa")
+	resolved, err := s.Temporal.Resolve(s.Target)
+	end()
+	if err != nil {
+		return err
+	}
+
+	end = s.Profiler.GPU("post")
+	out, err := s.Post.Apply(resolved)
+	end()
+
+	end = s.Profiler.GPU("blit")
+	out.BlitToScreen(s.width, s.height)
+	end()
+
+	// the post passes leave their own program bound
+	s.GLProgram.Use()
+	return err
+}
+
+// DrawOverlays draws the HUD and the debug UI over the window after End.
+// pixelRatio scales the text for HiDPI framebuffers.
+func (s *Program) DrawOverlays(pixelRatio float64) {
+	s.HUD.Draw(s.width, s.height, pixelRatio)
+	s.UI.Draw()
+	s.GLProgram.Use()
+}
+
+// Finish blocks until the GPU has executed everything queued so far.
+func (s *Program) Finish() {
+	gl.Finish()
 }
 
 func (s *Program) Clear() {
@@ -73,11 +320,18 @@ func (s *Program) Clear() {
 }
 
 func (s *Program) Draw() {
+	defer s.Profiler.GPU("raymarch")()
+
+	s.SetInt(DEBUG_MODE_UNIFORM, int(s.Debug))
+	s.Uniforms.update()
+	s.Blocks.Upload()
 	gl.BindVertexArray(s.VAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, 6)
 }
 
-func (s *Program) SetObjectsTextureData(data []uint8) uint32 {
+// SetObjectsTextureData uploads object data as a float texture with width
+// RGBA texels per row, one row per object.
+func (s *Program) SetObjectsTextureData(data []float32, width int) uint32 {
 	var texture uint32
 	gl.GenTextures(1, &texture)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
@@ -87,45 +341,88 @@ func (s *Program) SetObjectsTextureData(data []uint8) uint32 {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, 1, int32(len(data)/4), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(data))
+	height := len(data) / (4 * width)
+	if height == 0 {
+		// keep the texture valid for scenes without objects
+		data = make([]float32, 4*width)
+		height = 1
+	}
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA32F, int32(width), int32(height), 0, gl.RGBA, gl.FLOAT, gl.Ptr(data))
 
 	return texture
 }
 
+func (s *Program) DeleteTexture(texture uint32) {
+	gl.DeleteTextures(1, &texture)
+}
+
+// SetFade darkens everything drawn afterwards towards black, from 0 (off) to 1.
+func (s *Program) SetFade(fade float32) {
+	if fade <= 0 {
+		gl.Disable(gl.BLEND)
+		return
+	}
+
+	// scale the incoming color by the constant alpha, ignoring the cleared
+	// target. Alpha holds the hit distance and passes through untouched.
+	gl.Enable(gl.BLEND)
+	gl.BlendColor(0, 0, 0, 1-fade)
+	gl.BlendFuncSeparate(gl.CONSTANT_ALPHA, gl.ZERO, gl.ONE, gl.ZERO)
+}
+
 func (s *Program) SetClearColor(r, g, b, a float32) {
 	gl.ClearColor(r, g, b, a)
 }
 
 func (s *Program) SetTime(time float32) {
-	gl.Uniform1f(s.time, time)
+	s.Blocks.Frame.Time = time
+	s.Blocks.frame.Invalidate()
 }
 
 func (s *Program) SetCamera(camera *Camera) {
-	gl.Uniform3f(s.cameraPos, float32(camera.Pos.AtVec(0)), float32(camera.Pos.AtVec(1)), float32(camera.Pos.AtVec(2)))
-	gl.Uniform3f(s.cameraDir, float32(camera.Dir.AtVec(0)), float32(camera.Dir.AtVec(1)), float32(camera.Dir.AtVec(2)))
-	gl.Uniform3f(s.cameraUp, float32(camera.Up.AtVec(0)), float32(camera.Up.AtVec(1)), float32(camera.Up.AtVec(2)))
-	gl.Uniform1f(s.cameraFOV, camera.FOV)
+	data := &s.Blocks.Camera
+	data.Position = vec3(camera.Pos)
+	data.Direction = vec3(camera.Dir())
+	data.Up = vec3(camera.Up())
+	data.FOV = camera.FOV
+	s.Blocks.camera.Invalidate()
 }
 
 func (s *Program) SetLight(light *Light) {
-	gl.Uniform3f(s.lightPos, float32(light.Position.AtVec(0)), float32(light.Position.AtVec(1)), float32(light.Position.AtVec(2)))
+	s.Blocks.Light.Position = vec3(light.Position)
+	s.Blocks.light.Invalidate()
 }
 
 func (s *Program) SetResolution(width, height int) {
-	gl.Uniform2f(s.resolution, float32(width), float32(height))
+	s.Blocks.Frame.Resolution = [2]float32{float32(width), float32(height)}
+	s.Blocks.frame.Invalidate()
+}
+
+func (s *Program) SetObjectCount(count int) {
+	s.SetInt(OBJECT_COUNT_UNIFORM, count)
 }
 
 func (s *Program) SetData(data uint32) {
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, data)
-	gl.Uniform1i(s.texture, 0)
+	s.SetInt(DATA_UNIFORM_NAME, 0)
 }
 
 func (s *Program) Delete() {
 	gl.DeleteVertexArrays(1, &s.VAO)
+	s.Blocks.Delete()
+	s.Target.Delete()
+	s.Post.Delete()
+	s.Temporal.Delete()
+	s.HUD.Delete()
+	s.UI.Delete()
 	s.GLProgram.Delete()
 }
 
+func vec3(v *mat.VecDense) [3]float32 {
+	return [3]float32{float32(v.AtVec(0)), float32(v.AtVec(1)), float32(v.AtVec(2))}
+}
+
 func createTriangleVAO(vertices []float32) uint32 {
 	var vao, vbo uint32
 	gl.GenVertexArrays(1, &vao)
@@ -0,0 +1,39 @@
+package program
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ScreenRay returns the world space ray through window position (x, y) of a
+// w by h window, built the same way main() in fragment.glsl builds
+// ray_direction. The origin is the camera position.
+func (c *Camera) ScreenRay(x, y float64, w, h int) (origin, direction *mat.VecDense) {
+	aspect := float64(h) / float64(w)
+
+	// window coordinates grow downwards, TexCoords grow upwards
+	u := 2*(x/float64(w)) - 1
+	v := (2*(1-y/float64(h)) - 1) * aspect
+
+	fovFactor := math.Tan(float64(c.FOV) * 0.5 * math.Pi / 180)
+
+	forward, right, up := c.Dir(), c.Right(), c.Up()
+
+	direction = mat.NewVecDense(3, nil)
+	direction.AddScaledVec(forward, fovFactor*u, right)
+	direction.AddScaledVec(direction, fovFactor*v, up)
+
+	origin = mat.NewVecDense(3, nil)
+	origin.CopyVec(c.Pos)
+	return origin, normalized(direction)
+}
+
+func normalized(v *mat.VecDense) *mat.VecDense {
+	n := mat.NewVecDense(3, nil)
+	n.CopyVec(v)
+	if l := n.Norm(2); l > 0 {
+		n.ScaleVec(1/l, n)
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package program
+
+import (
+	"math"
+	"strings"
+)
+
+// Preset is a named set of render quality settings.
+type Preset struct {
+	Name     string
+	Scale    float64
+	MaxSteps int
+	Quality  int
+}
+
+var Presets = []Preset{
+	{Name: "low", Scale: 0.5, MaxSteps: 64, Quality: 0},
+	{Name: "medium", Scale: 0.75, MaxSteps: 96, Quality: 1},
+	{Name: "high", Scale: 1, MaxSteps: DefaultMaxSteps, Quality: DefaultQuality},
+	{Name: "ultra", Scale: 1, MaxSteps: 256, Quality: 2},
+}
+
+func PresetByName(name string) (Preset, bool) {
+	for _, preset := range Presets {
+		if strings.EqualFold(preset.Name, name) {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// ResolutionScaler picks the internal render resolution as a fraction of the
+// window. With Auto set it steers Scale towards TargetFrameTime.
+type ResolutionScaler struct {
+	Scale           float64
+	MinScale        float64
+	MaxScale        float64
+	TargetFrameTime float64
+	Auto            bool
+
+	average  float64
+	cooldown float64
+}
+
+func NewResolutionScaler(targetFPS float64) *ResolutionScaler {
+	return &ResolutionScaler{
+		Scale:           1,
+		MinScale:        0.25,
+		MaxScale:        1,
+		TargetFrameTime: 1 / targetFPS,
+	}
+}
+
+// Update feeds the time the last frame took to render, without waiting for
+// vsync or a frame cap, into the controller. Scale changes at most every
+// quarter second of rendering and in steps of 5% so the framebuffer is not
+// reallocated every frame.
+func (r *ResolutionScaler) Update(frameTime float64) {
+	if !r.Auto || frameTime <= 0 {
+		return
+	}
+
+	if r.average == 0 {
+		r.average = frameTime
+	}
+	r.average += (frameTime - r.average) * 0.1
+
+	r.cooldown -= frameTime
+	if r.cooldown > 0 {
+		return
+	}
+	r.cooldown = 0.25
+
+	// leave some headroom below the target before scaling back up
+	if r.average < r.TargetFrameTime*1.02 && r.average > r.TargetFrameTime*0.85 {
+		return
+	}
+
+	// shading cost follows the pixel count, which grows with the square of
+	// the scale
+	scale := r.Scale * math.Sqrt(r.TargetFrameTime/r.average)
+	scale = math.Max(r.Scale-0.1, math.Min(r.Scale+0.1, scale))
+	scale = math.Round(scale*20) / 20
+	r.Scale = math.Max(r.MinScale, math.Min(r.MaxScale, scale))
+}
+
+// Size returns the internal resolution for a window of width by height.
+func (r *ResolutionScaler) Size(width, height int) (int, int) {
+	w := int(math.Round(float64(width) * r.Scale))
+	h := int(math.Round(float64(height) * r.Scale))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
@@ -14,11 +14,6 @@ func NewScene() *Scene {
 		Light: &Light{
 			Position: mat.NewVecDense(3, []float64{0, 64, -64}),
 		},
-		Camera: &Camera{
-			Pos: mat.NewVecDense(3, []float64{-32, 0, -32}),
-			Dir: mat.NewVecDense(3, []float64{0, 0, 1}),
-			Up:  mat.NewVecDense(3, []float64{0, 1, 0}),
-			FOV: float32(90),
-		},
+		Camera: NewCamera(mat.NewVecDense(3, []float64{-32, 0, -32}), float32(90)),
 	}
 }
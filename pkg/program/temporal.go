@@ -0,0 +1,119 @@
+package program
+
+import (
+	glprogram "remnant/pkg/gl"
+)
+
+// Temporal accumulates frames rendered with a different sub-pixel jitter
+// each into a history buffer. Reprojection through the previous camera keeps
+// the history lined up while moving, so edges, soft shadows and fbm detail
+// converge over a few frames instead of shimmering.
+type Temporal struct {
+	Enabled bool
+	// Feedback is how much of the history is kept each frame.
+	Feedback float32
+
+	pass    *glprogram.ShaderPass
+	history [2]*glprogram.Framebuffer
+	current int
+	valid   bool
+	frame   int
+}
+
+func NewTemporal(pp *glprogram.Preprocessor) (*Temporal, error) {
+	pass, err := glprogram.NewShaderPass(pp, "taa", "shaders/post/taa.glsl", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Temporal{
+		Enabled:  true,
+		Feedback: 0.9,
+		pass:     pass,
+	}, nil
+}
+
+// Jitter advances to the next frame and returns its sub-pixel offset in
+// pixels, following the Halton (2, 3) sequence.
+func (t *Temporal) Jitter() [2]float32 {
+	t.frame++
+	if !t.Enabled {
+		return [2]float32{}
+	}
+
+	i := t.frame%16 + 1
+	return [2]float32{halton(i, 2) - 0.5, halton(i, 3) - 0.5}
+}
+
+// Invalidate drops the history, for camera cuts and resizes: scene
+// switches, teleports and a camera path looping.
+func (t *Temporal) Invalidate() {
+	t.valid = false
+}
+
+// Resolve blends src into the history and returns the framebuffer holding
+// the result. The frame uniform blocks must be bound to the pass.
+func (t *Temporal) Resolve(src *glprogram.Framebuffer) (*glprogram.Framebuffer, error) {
+	if !t.Enabled {
+		t.valid = false
+		return src, nil
+	}
+
+	for i := range t.history {
+		if t.history[i] == nil {
+			fb, err := glprogram.NewFramebuffer(src.Width, src.Height)
+			if err != nil {
+				return src, err
+			}
+			t.history[i] = fb
+			t.valid = false
+		} else if t.history[i].Width != src.Width || t.history[i].Height != src.Height {
+			if err := t.history[i].Resize(src.Width, src.Height); err != nil {
+				return src, err
+			}
+			t.valid = false
+		}
+	}
+
+	previous, next := t.history[t.current], t.history[1-t.current]
+
+	valid := float32(0)
+	if t.valid {
+		valid = 1
+	}
+	t.pass.Set("feedback", t.Feedback)
+	t.pass.Set("history_valid", valid)
+	t.pass.Texture("history", previous.Texture)
+	t.pass.Apply(src, next)
+
+	t.current = 1 - t.current
+	t.valid = true
+	return next, nil
+}
+
+func (t *Temporal) Program() *glprogram.GLProgram {
+	return t.pass.Program()
+}
+
+func (t *Temporal) Stage(r *glprogram.Reload) error {
+	return t.pass.Stage(r)
+}
+
+func (t *Temporal) Delete() {
+	t.pass.Delete()
+	for _, fb := range t.history {
+		if fb != nil {
+			fb.Delete()
+		}
+	}
+}
+
+func halton(i, base int) float32 {
+	f, r := float32(1), float32(0)
+	for i > 0 {
+		f /= float32(base)
+		r += f * float32(i%base)
+		i /= base
+	}
+	return r
+}
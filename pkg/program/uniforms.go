@@ -0,0 +1,191 @@
+package program
+
+import (
+	"log"
+	glprogram "remnant/pkg/gl"
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"gonum.org/v1/gonum/mat"
+)
+
+// UniformSource supplies the value of a custom uniform every frame. It may
+// return a float32, float64, int, bool or a *mat.VecDense of length 2 to 4.
+type UniformSource func() interface{}
+
+// Uniforms is the set of active uniforms of the running shader program,
+// looked up by name. Values are remembered so they survive a Reload.
+type Uniforms struct {
+	active  map[string]glprogram.ActiveUniform
+	values  map[string]uniformValue
+	sources map[string]UniformSource
+	warned  map[string]bool
+	checked bool
+}
+
+type uniformValue struct {
+	xtype uint32
+	f     [4]float32
+	i     int32
+}
+
+func NewUniforms() *Uniforms {
+	return &Uniforms{
+		active:  map[string]glprogram.ActiveUniform{},
+		values:  map[string]uniformValue{},
+		sources: map[string]UniformSource{},
+		warned:  map[string]bool{},
+	}
+}
+
+// bind switches to a newly linked program and uploads the remembered values.
+// The program must be in use.
+func (u *Uniforms) bind(program *glprogram.GLProgram) {
+	u.active = map[string]glprogram.ActiveUniform{}
+	for _, uniform := range program.ActiveUniforms() {
+		u.active[uniform.Name] = uniform
+	}
+	u.warned = map[string]bool{}
+	u.checked = false
+
+	for name, value := range u.values {
+		u.upload(name, value)
+	}
+}
+
+// Active reports whether name is a uniform of the running program.
+func (u *Uniforms) Active(name string) bool {
+	_, ok := u.active[name]
+	return ok
+}
+
+// Names lists the active uniforms in alphabetical order.
+func (u *Uniforms) Names() []string {
+	names := make([]string, 0, len(u.active))
+	for name := range u.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register sets name from source before every draw, replacing any previous
+// source for the same name.
+func (u *Uniforms) Register(name string, source UniformSource) {
+	u.sources[name] = source
+}
+
+func (u *Uniforms) Unregister(name string) {
+	delete(u.sources, name)
+	delete(u.values, name)
+}
+
+func (u *Uniforms) set(name string, value uniformValue) {
+	u.values[name] = value
+	u.upload(name, value)
+}
+
+func (u *Uniforms) upload(name string, value uniformValue) {
+	uniform, ok := u.active[name]
+	if !ok {
+		u.warn(name, "uniform %q is not active in the shader, it may have been optimised out", name)
+		return
+	}
+	if !compatible(uniform.Type, value.xtype) {
+		u.warn(name, "uniform %q is a %s but was set as a %s", name, glprogram.TypeName(uniform.Type), glprogram.TypeName(value.xtype))
+		return
+	}
+
+	switch value.xtype {
+	case gl.FLOAT:
+		gl.Uniform1f(uniform.Location, value.f[0])
+	case gl.FLOAT_VEC2:
+		gl.Uniform2f(uniform.Location, value.f[0], value.f[1])
+	case gl.FLOAT_VEC3:
+		gl.Uniform3f(uniform.Location, value.f[0], value.f[1], value.f[2])
+	case gl.FLOAT_VEC4:
+		gl.Uniform4f(uniform.Location, value.f[0], value.f[1], value.f[2], value.f[3])
+	case gl.INT:
+		gl.Uniform1i(uniform.Location, value.i)
+	}
+}
+
+// update evaluates the registered sources and, once per program, warns about
+// active uniforms nothing has set.
+func (u *Uniforms) update() {
+	for name, source := range u.sources {
+		value, ok := sourceValue(source())
+		if !ok {
+			u.warn(name, "uniform %q source returned an unsupported type", name)
+			continue
+		}
+		u.set(name, value)
+	}
+
+	if u.checked {
+		return
+	}
+	u.checked = true
+	for name := range u.active {
+		if _, ok := u.values[name]; !ok {
+			u.warn(name, "uniform %q is never set", name)
+		}
+	}
+}
+
+// warn logs once per uniform and program.
+func (u *Uniforms) warn(name, format string, args ...interface{}) {
+	if u.warned[name] {
+		return
+	}
+	u.warned[name] = true
+	log.Printf(format, args...)
+}
+
+// compatible reports whether a value set as xtype can be uploaded to an
+// active uniform. Samplers and bools are set through integers.
+func compatible(active, xtype uint32) bool {
+	if active == xtype {
+		return true
+	}
+	return xtype == gl.INT && (active == gl.SAMPLER_2D || active == gl.BOOL)
+}
+
+func sourceValue(v interface{}) (uniformValue, bool) {
+	switch v := v.(type) {
+	case float32:
+		return floatValue(v), true
+	case float64:
+		return floatValue(float32(v)), true
+	case int:
+		return uniformValue{xtype: gl.INT, i: int32(v)}, true
+	case bool:
+		value := uniformValue{xtype: gl.INT}
+		if v {
+			value.i = 1
+		}
+		return value, true
+	case *mat.VecDense:
+		return vecValue(v)
+	}
+	return uniformValue{}, false
+}
+
+func floatValue(v float32) uniformValue {
+	return uniformValue{xtype: gl.FLOAT, f: [4]float32{v}}
+}
+
+var vecTypes = [...]uint32{2: gl.FLOAT_VEC2, 3: gl.FLOAT_VEC3, 4: gl.FLOAT_VEC4}
+
+func vecValue(v *mat.VecDense) (uniformValue, bool) {
+	n := v.Len()
+	if n < 2 || n > 4 {
+		return uniformValue{}, false
+	}
+
+	value := uniformValue{xtype: vecTypes[n]}
+	for i := 0; i < n; i++ {
+		value.f[i] = float32(v.AtVec(i))
+	}
+	return value, true
+}
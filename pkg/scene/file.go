@@ -0,0 +1,519 @@
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"remnant/internal/controller"
+	"remnant/pkg/physics"
+	"remnant/pkg/program"
+	"remnant/pkg/ship"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// File is the JSON scene format read by LoadFile. Vectors are [x, y, z]
+// arrays, rotations are [yaw, pitch, roll] in degrees and colors are linear
+// [r, g, b] in 0..1.
+type File struct {
+	Name        string       `json:"name"`
+	Camera      CameraDesc   `json:"camera"`
+	Lights      []LightDesc  `json:"lights"`
+	Objects     []ObjectDesc `json:"objects"`
+	SpawnPoints []SpawnDesc  `json:"spawn_points"`
+	Ships       []ShipDesc   `json:"ships"`
+	// Player names the ship the player flies, the first ship by default.
+	Player string `json:"player"`
+}
+
+type CameraDesc struct {
+	Position  []float64 `json:"position"`
+	Direction []float64 `json:"direction"`
+	Up        []float64 `json:"up"`
+	FOV       float32   `json:"fov"`
+}
+
+type LightDesc struct {
+	Position []float64 `json:"position"`
+}
+
+type ObjectDesc struct {
+	Kind     string       `json:"kind"`
+	Position []float64    `json:"position"`
+	Rotation []float64    `json:"rotation"`
+	Scale    *float64     `json:"scale"`
+	Radius   float64      `json:"radius"`
+	Size     []float64    `json:"size"`
+	Material MaterialDesc `json:"material"`
+	Atmos    *AtmosDesc   `json:"atmosphere"`
+}
+
+type MaterialDesc struct {
+	Color    []float64 `json:"color"`
+	Emission float64   `json:"emission"`
+}
+
+// AtmosDesc gives a sphere an atmosphere for physics.AtmosphericDrag.
+type AtmosDesc struct {
+	Density     float64 `json:"density"`
+	ScaleHeight float64 `json:"scale_height"`
+}
+
+type SpawnDesc struct {
+	Name      string    `json:"name"`
+	Position  []float64 `json:"position"`
+	Direction []float64 `json:"direction"`
+}
+
+type ShipDesc struct {
+	Name            string  `json:"name"`
+	Spawn           string  `json:"spawn"`
+	Mass            float64 `json:"mass"`
+	LinearDrag      float64 `json:"linear_drag"`
+	QuadraticDrag   float64 `json:"quadratic_drag"`
+	AtmosphericDrag float64 `json:"atmospheric_drag"`
+}
+
+var objectKinds = map[string]int{
+	"terrain-sphere": TerrainSphereKind,
+	"sphere":         SphereKind,
+	"box":            BoxKind,
+}
+
+// FileError locates a problem in a scene file.
+type FileError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// FileErrors is every validation problem found in a file.
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// LoadFile parses and validates a scene file.
+func LoadFile(file string) (*File, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFile(file, data)
+}
+
+func ParseFile(file string, data []byte) (*File, error) {
+	f := &File{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		// syntax and type error offsets are just past what the decoder
+		// choked on, unknown fields are only noticed at the end
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset - 1
+		case errors.As(err, &typeErr):
+			offset = valueStart(data, typeErr.Offset)
+		case errors.Is(err, io.EOF):
+			err = errors.New("empty scene file")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			if name, qerr := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field ")); qerr == nil {
+				offset = fieldOffset(data, name, offset)
+			}
+		}
+		line, col := lineColumn(data, offset)
+		return nil, &FileError{File: file, Line: line, Column: col, Msg: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+
+	v := &validator{file: file, data: data, offsets: valueOffsets(data)}
+	f.validate(v)
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+	return f, nil
+}
+
+// Build creates the scene. The file must have been validated.
+func (f *File) Build(ctr *controller.Controller) *SceneB {
+	light := program.NewLight(vec(f.Lights[0].Position))
+
+	cam := program.NewCamera(vec(f.Camera.Position), f.Camera.FOV)
+	cam.SetBasis(vec(f.Camera.Direction), vec(orDefault(f.Camera.Up, []float64{0, 1, 0})))
+
+	player := f.player()
+	position, direction := f.Camera.Position, f.Camera.Direction
+	if player != nil && player.Spawn != "" {
+		spawn := f.spawn(player.Spawn)
+		position, direction = spawn.Position, orDefault(spawn.Direction, direction)
+	}
+
+	person := ship.NewShip(vec(position))
+	person.Orientation = physics.QuaternionFromBasis(vec(direction), vec(orDefault(f.Camera.Up, []float64{0, 1, 0})))
+
+	s := newSceneB(ctr, light, cam, person)
+	s.name = f.Name
+
+	objects := make([]Object, len(f.Objects))
+	for i, o := range f.Objects {
+		objects[i] = o.object()
+		if o.Atmos != nil {
+			s.planets = append(s.planets, physics.NewPlanet(vec(o.Position), o.Radius*objects[i].Scale, o.Atmos.Density, o.Atmos.ScaleHeight))
+		}
+	}
+	s.setObjects(objects)
+
+	if player != nil {
+		if player.Mass > 0 {
+			person.Mass = player.Mass
+		}
+		if player.LinearDrag > 0 || player.QuadraticDrag > 0 {
+			person.AddForceGenerator(&physics.Drag{K1: player.LinearDrag, K2: player.QuadraticDrag})
+		}
+		if player.AtmosphericDrag > 0 && len(s.planets) > 0 {
+			person.AddForceGenerator(physics.NewAtmosphericDrag(player.AtmosphericDrag, s.planets...))
+		}
+	}
+
+	return s
+}
+
+func (f *File) player() *ShipDesc {
+	for i := range f.Ships {
+		if f.Ships[i].Name == f.Player {
+			return &f.Ships[i]
+		}
+	}
+	if f.Player == "" && len(f.Ships) > 0 {
+		return &f.Ships[0]
+	}
+	return nil
+}
+
+func (f *File) spawn(name string) *SpawnDesc {
+	for i := range f.SpawnPoints {
+		if f.SpawnPoints[i].Name == name {
+			return &f.SpawnPoints[i]
+		}
+	}
+	return nil
+}
+
+func (o *ObjectDesc) object() Object {
+	obj := Object{
+		Kind:     objectKinds[o.Kind],
+		Scale:    1,
+		Rotation: eulerQuaternion(orDefault(o.Rotation, []float64{0, 0, 0})),
+		Color:    defaultColor,
+	}
+	copy(obj.Position[:], o.Position)
+	if o.Scale != nil {
+		obj.Scale = *o.Scale
+	}
+	if o.Kind == "box" {
+		copy(obj.Size[:], o.Size)
+	} else {
+		obj.Size = [3]float64{o.Radius, o.Radius, o.Radius}
+	}
+	if o.Material.Color != nil {
+		copy(obj.Color[:], o.Material.Color)
+	}
+	obj.Emission = o.Material.Emission
+	return obj
+}
+
+func (f *File) validate(v *validator) {
+	if f.Name == "" {
+		v.errorf("name", "scene needs a name")
+	}
+	v.vector("camera.position", f.Camera.Position, true)
+	v.direction("camera.direction", f.Camera.Direction, true)
+	v.direction("camera.up", f.Camera.Up, false)
+	// ships and the camera are oriented with camera.up, which can't be
+	// along the way they face
+	up := orDefault(f.Camera.Up, []float64{0, 1, 0})
+	v.notAlong("camera.direction", f.Camera.Direction, up)
+	if f.Camera.FOV <= 0 || f.Camera.FOV >= 180 {
+		v.errorf("camera.fov", "fov must be between 0 and 180 degrees, got %v", f.Camera.FOV)
+	}
+
+	// fragment.glsl only shades with a single light
+	if len(f.Lights) != 1 {
+		v.errorf("lights", "exactly one light is supported, got %d", len(f.Lights))
+	}
+	for i, l := range f.Lights {
+		v.vector(fmt.Sprintf("lights[%d].position", i), l.Position, true)
+	}
+
+	if len(f.Objects) > program.MaxObjects {
+		v.errorf("objects", "at most %d objects are supported, got %d", program.MaxObjects, len(f.Objects))
+	}
+	for i, o := range f.Objects {
+		path := fmt.Sprintf("objects[%d]", i)
+		kind, ok := objectKinds[o.Kind]
+		if !ok {
+			v.errorf(path+".kind", "unknown object kind %q", o.Kind)
+		}
+		v.vector(path+".position", o.Position, true)
+		v.vector(path+".rotation", o.Rotation, false)
+		if o.Scale != nil && *o.Scale <= 0 {
+			v.errorf(path+".scale", "scale must be positive")
+		}
+		if ok && kind == BoxKind {
+			v.vector(path+".size", o.Size, true)
+			for _, s := range o.Size {
+				if s <= 0 {
+					v.errorf(path+".size", "box size must be positive")
+					break
+				}
+			}
+		} else if ok && o.Radius <= 0 {
+			v.errorf(path+".radius", "radius must be positive")
+		}
+		if o.Material.Color != nil {
+			v.vector(path+".material.color", o.Material.Color, true)
+			for _, c := range o.Material.Color {
+				if c < 0 || c > 1 {
+					v.errorf(path+".material.color", "color components must be within 0..1")
+					break
+				}
+			}
+		}
+		if o.Material.Emission < 0 {
+			v.errorf(path+".material.emission", "emission must not be negative")
+		}
+		if o.Atmos != nil {
+			if ok && kind == BoxKind {
+				v.errorf(path+".atmosphere", "only spheres can have an atmosphere")
+			}
+			if o.Atmos.Density < 0 || o.Atmos.ScaleHeight <= 0 {
+				v.errorf(path+".atmosphere", "atmosphere needs a non-negative density and positive scale_height")
+			}
+		}
+	}
+
+	spawns := map[string]bool{}
+	for i, sp := range f.SpawnPoints {
+		path := fmt.Sprintf("spawn_points[%d]", i)
+		if sp.Name == "" {
+			v.errorf(path, "spawn point needs a name")
+		} else if spawns[sp.Name] {
+			v.errorf(path+".name", "duplicate spawn point %q", sp.Name)
+		}
+		spawns[sp.Name] = true
+		v.vector(path+".position", sp.Position, true)
+		v.direction(path+".direction", sp.Direction, false)
+		v.notAlong(path+".direction", sp.Direction, up)
+	}
+
+	ships := map[string]bool{}
+	for i, sh := range f.Ships {
+		path := fmt.Sprintf("ships[%d]", i)
+		if sh.Name == "" {
+			v.errorf(path, "ship needs a name")
+		} else if ships[sh.Name] {
+			v.errorf(path+".name", "duplicate ship %q", sh.Name)
+		}
+		ships[sh.Name] = true
+		if sh.Spawn != "" && !spawns[sh.Spawn] {
+			v.errorf(path+".spawn", "unknown spawn point %q", sh.Spawn)
+		}
+		if sh.Mass < 0 || sh.LinearDrag < 0 || sh.QuadraticDrag < 0 || sh.AtmosphericDrag < 0 {
+			v.errorf(path, "ship parameters must not be negative")
+		}
+	}
+	if f.Player != "" && !ships[f.Player] {
+		v.errorf("player", "unknown ship %q", f.Player)
+	}
+}
+
+type validator struct {
+	file    string
+	data    []byte
+	offsets map[string]int64
+	errs    FileErrors
+}
+
+// errorf reports an error at path, or at the closest parent present in the
+// file when path itself is missing.
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	offset, ok := v.offsets[path]
+	for !ok && path != "" {
+		if i := strings.LastIndexAny(path, ".["); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+		offset, ok = v.offsets[path]
+	}
+
+	line, col := lineColumn(v.data, offset)
+	v.errs = append(v.errs, &FileError{File: v.file, Line: line, Column: col, Msg: path + ": " + fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) vector(path string, x []float64, required bool) {
+	if x == nil {
+		if required {
+			v.errorf(path, "missing %s", path)
+		}
+		return
+	}
+	if len(x) != 3 {
+		v.errorf(path, "expected 3 components, got %d", len(x))
+	}
+}
+
+func (v *validator) direction(path string, x []float64, required bool) {
+	v.vector(path, x, required)
+	if len(x) == 3 && x[0] == 0 && x[1] == 0 && x[2] == 0 {
+		v.errorf(path, "direction must not be zero")
+	}
+}
+
+// notAlong reports an error if direction points along or against up.
+func (v *validator) notAlong(path string, direction, up []float64) {
+	if len(direction) != 3 || len(up) != 3 {
+		return
+	}
+	d, u := vec(direction), vec(up)
+	if d.Norm(2) == 0 || u.Norm(2) == 0 {
+		return
+	}
+	if physics.Cross(d, u).Norm(2) <= 1e-6*d.Norm(2)*u.Norm(2) {
+		v.errorf(path, "direction must not be parallel to camera.up")
+	}
+}
+
+// valueOffsets maps every value in a JSON document, by path such as
+// "objects[2].kind", to the byte offset where it starts.
+func valueOffsets(data []byte) map[string]int64 {
+	offsets := map[string]int64{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		start := dec.InputOffset()
+		for start < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[start]) >= 0 {
+			start++
+		}
+		offsets[path] = start
+
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := key.(string)
+				if path != "" {
+					child = path + "." + child
+				}
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(path + "[" + strconv.Itoa(i) + "]"); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+
+	return offsets
+}
+
+// fieldOffset returns where the value of the first field called name
+// begins, or def if there is none.
+func fieldOffset(data []byte, name string, def int64) int64 {
+	found := false
+	for path, o := range valueOffsets(data) {
+		if (path == name || strings.HasSuffix(path, "."+name)) && (!found || o < def) {
+			def, found = o, true
+		}
+	}
+	return def
+}
+
+// valueStart returns where the last value starting before offset begins.
+func valueStart(data []byte, offset int64) int64 {
+	start := int64(0)
+	for _, o := range valueOffsets(data) {
+		if o < offset && o > start {
+			start = o
+		}
+	}
+	return start
+}
+
+// lineColumn converts a byte offset into a 1-based line and column.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// eulerQuaternion applies yaw, pitch and roll in degrees about the local
+// axes, in the same order as Camera.Rotate.
+func eulerQuaternion(degrees []float64) quat.Number {
+	rad := math.Pi / 180
+	q := quat.Mul(
+		physics.CreateRotationQuaternion(degrees[0]*rad, mat.NewVecDense(3, []float64{0, 1, 0})),
+		physics.CreateRotationQuaternion(degrees[1]*rad, mat.NewVecDense(3, []float64{1, 0, 0})),
+	)
+	q = quat.Mul(q, physics.CreateRotationQuaternion(degrees[2]*rad, mat.NewVecDense(3, []float64{0, 0, 1})))
+	return physics.NormalizeQuaternion(q)
+}
+
+func vec(x []float64) *mat.VecDense {
+	v := mat.NewVecDense(3, nil)
+	for i := 0; i < 3 && i < len(x); i++ {
+		v.SetVec(i, x[i])
+	}
+	return v
+}
+
+func orDefault(x, def []float64) []float64 {
+	if x == nil {
+		return def
+	}
+	return x
+}
@@ -0,0 +1,142 @@
+package scene_test
+
+import (
+	"strings"
+	"testing"
+
+	"remnant/pkg/scene"
+)
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "valid",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 },
+  "lights": [{ "position": [10, 10, 0] }]
+}`,
+		},
+		{
+			name: "empty",
+			doc:  ``,
+			want: []string{"test.json:1:1: empty scene file"},
+		},
+		{
+			name: "syntax error",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10],, }
+}`,
+			want: []string{"test.json:3:39: invalid character ',' looking for beginning of object key string"},
+		},
+		{
+			name: "wrong type",
+			doc: `{
+  "name": 4
+}`,
+			want: []string{"test.json:2:11: cannot unmarshal number"},
+		},
+		{
+			name: "unknown field",
+			doc: `{
+  "name": "test",
+  "gravity": 9.8
+}`,
+			want: []string{`test.json:3:14: unknown field "gravity"`},
+		},
+		{
+			name: "missing name",
+			doc: `{
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 },
+  "lights": [{ "position": [10, 10, 0] }]
+}`,
+			want: []string{"test.json:1:1: : scene needs a name"},
+		},
+		{
+			name: "bad vector and fov",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0], "direction": [0, 0, 1], "fov": 200 },
+  "lights": [{ "position": [10, 10, 0] }]
+}`,
+			want: []string{
+				"test.json:3:27: camera.position: expected 3 components, got 2",
+				"test.json:3:66: camera.fov: fov must be between 0 and 180 degrees, got 200",
+			},
+		},
+		{
+			name: "missing light",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 }
+}`,
+			want: []string{"test.json:1:1: : exactly one light is supported, got 0"},
+		},
+		{
+			name: "unknown object kind",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 },
+  "lights": [{ "position": [10, 10, 0] }],
+  "objects": [
+    { "kind": "torus", "position": [0, 0, 0], "radius": 1 }
+  ]
+}`,
+			want: []string{`test.json:6:15: objects[0].kind: unknown object kind "torus"`},
+		},
+		{
+			name: "spawn facing up",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 },
+  "lights": [{ "position": [10, 10, 0] }],
+  "spawn_points": [
+    { "name": "top", "position": [0, 10, 0], "direction": [0, 2, 0] }
+  ]
+}`,
+			want: []string{"test.json:6:59: spawn_points[0].direction: direction must not be parallel to camera.up"},
+		},
+		{
+			name: "unknown player",
+			doc: `{
+  "name": "test",
+  "camera": { "position": [0, 0, -10], "direction": [0, 0, 1], "fov": 60 },
+  "lights": [{ "position": [10, 10, 0] }],
+  "player": "ghost"
+}`,
+			want: []string{`test.json:5:13: player: unknown ship "ghost"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := scene.ParseFile("test.json", []byte(tt.doc))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("no error")
+			}
+
+			got := strings.Split(err.Error(), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d:\n%v", len(got), len(tt.want), err)
+			}
+			// the end of the decoder's own messages varies between Go
+			// versions, only the start is compared
+			for i := range got {
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("error %d:\n got %s\nwant %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package scene
+
+import (
+	"math"
+	"remnant/pkg/hud"
+	"remnant/pkg/program"
+	"remnant/pkg/sdf"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// printFlight shows where cam is and where it is heading in the bottom left
+// corner of the HUD. Heading is measured around the y axis from +z.
+func printFlight(h *hud.HUD, cam *program.Camera, velocity *mat.VecDense) {
+	dir := cam.Dir()
+	heading := math.Atan2(dir.AtVec(0), dir.AtVec(2)) * 180 / math.Pi
+	if heading < 0 {
+		heading += 360
+	}
+	pitch := math.Asin(math.Max(-1, math.Min(1, dir.AtVec(1)))) * 180 / math.Pi
+
+	h.Printf(hud.BottomLeft, "pos %8.1f %8.1f %8.1f", cam.Pos.AtVec(0), cam.Pos.AtVec(1), cam.Pos.AtVec(2))
+	h.Printf(hud.BottomLeft, "vel %6.1f", velocity.Norm(2))
+	h.Printf(hud.BottomLeft, "hdg %03.0f  pitch %+03.0f", heading, pitch)
+}
+
+// printTarget names the object in the middle of the view below the center
+// of the HUD. cam is the camera the frame is drawn with, so the target is
+// what is under the crosshair.
+func printTarget(h *hud.HUD, world *sdf.Scene, cam *program.Camera) {
+	if hit, ok := world.Pick(cam.ScreenRay(0.5, 0.5, 1, 1)); ok {
+		h.Printf(hud.Bottom, "target #%d  %.1f", hit.ID, hit.Distance)
+	}
+}
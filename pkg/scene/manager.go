@@ -0,0 +1,211 @@
+package scene
+
+import (
+	"fmt"
+	"remnant/pkg/input"
+	"remnant/pkg/program"
+	"sort"
+)
+
+type opKind int
+
+const (
+	pushOp opKind = iota
+	popOp
+	replaceOp
+)
+
+type op struct {
+	kind  opKind
+	scene Scene
+}
+
+// Manager keeps a stack of scenes. Only the top scene is updated, rendered
+// and receives input. Stack changes are queued and applied by the next
+// Advance, fading out and back in over TransitionTime seconds.
+type Manager struct {
+	Program        *program.Program
+	TransitionTime float64
+
+	stack     []Scene
+	pending   []op
+	factories map[string]func() Scene
+
+	fading  bool
+	elapsed float64
+}
+
+func NewManager(transitionTime float64) *Manager {
+	return &Manager{
+		TransitionTime: transitionTime,
+		factories:      make(map[string]func() Scene),
+	}
+}
+
+// Register makes a scene constructor available by name to LoadNamed.
+func (m *Manager) Register(name string, factory func() Scene) {
+	m.factories[name] = factory
+}
+
+func (m *Manager) Unregister(name string) {
+	delete(m.factories, name)
+}
+
+func (m *Manager) Registered(name string) bool {
+	_, ok := m.factories[name]
+	return ok
+}
+
+func (m *Manager) Names() []string {
+	names := make([]string, 0, len(m.factories))
+	for name := range m.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadNamed replaces the current scene with a new instance of a registered one.
+func (m *Manager) LoadNamed(name string) error {
+	factory, ok := m.factories[name]
+	if !ok {
+		return fmt.Errorf("unknown scene %q", name)
+	}
+	m.Replace(factory())
+	return nil
+}
+
+func (m *Manager) Push(s Scene) {
+	m.queue(op{kind: pushOp, scene: s})
+}
+
+func (m *Manager) Pop() {
+	m.queue(op{kind: popOp})
+}
+
+func (m *Manager) Replace(s Scene) {
+	m.queue(op{kind: replaceOp, scene: s})
+}
+
+func (m *Manager) Current() Scene {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return m.stack[len(m.stack)-1]
+}
+
+// Empty reports whether there is nothing left to run.
+func (m *Manager) Empty() bool {
+	return len(m.stack) == 0 && len(m.pending) == 0
+}
+
+// Fade is how dark the frame should be drawn, from 0 to 1, while a
+// transition is in progress.
+func (m *Manager) Fade() float64 {
+	if !m.fading || m.TransitionTime <= 0 {
+		return 0
+	}
+
+	half := m.TransitionTime / 2
+	if m.elapsed < half {
+		return m.elapsed / half
+	}
+	return 1 - (m.elapsed-half)/half
+}
+
+// Update runs the current scene for dt of simulated time.
+func (m *Manager) Update(dt float64) error {
+	if s := m.Current(); s != nil {
+		return s.Update(dt)
+	}
+	return nil
+}
+
+func (m *Manager) Render(program *program.Program) error {
+	if s := m.Current(); s != nil {
+		return s.Render(program)
+	}
+	return nil
+}
+
+func (m *Manager) HandleInput(event input.Event) {
+	if s := m.Current(); s != nil {
+		s.HandleInput(event)
+	}
+}
+
+// Clear unloads every scene on the stack, top first.
+func (m *Manager) Clear() {
+	for len(m.stack) > 0 {
+		m.pop()
+	}
+	m.pending = nil
+}
+
+func (m *Manager) queue(o op) {
+	m.pending = append(m.pending, o)
+	if m.TransitionTime > 0 && len(m.stack) > 0 && !m.fading {
+		m.fading = true
+		m.elapsed = 0
+	}
+}
+
+// Advance steps the transition by dt of real time, so it takes as long
+// whatever the time scale, applying queued stack changes once the screen is
+// fully faded out, or immediately if there is no transition. It is called
+// before Update.
+func (m *Manager) Advance(dt float64) error {
+	if !m.fading {
+		return m.apply()
+	}
+
+	before := m.elapsed
+	m.elapsed += dt
+	half := m.TransitionTime / 2
+	if before < half && m.elapsed >= half {
+		if err := m.apply(); err != nil {
+			return err
+		}
+	}
+	if m.elapsed >= m.TransitionTime {
+		m.fading = false
+	}
+	return nil
+}
+
+func (m *Manager) apply() error {
+	pending := m.pending
+	m.pending = nil
+
+	for _, o := range pending {
+		switch o.kind {
+		case pushOp:
+			if err := m.push(o.scene); err != nil {
+				return err
+			}
+		case popOp:
+			m.pop()
+		case replaceOp:
+			m.pop()
+			if err := m.push(o.scene); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) push(s Scene) error {
+	if err := s.Load(m.Program); err != nil {
+		return err
+	}
+	m.stack = append(m.stack, s)
+	return nil
+}
+
+func (m *Manager) pop() {
+	if s := m.Current(); s != nil {
+		s.Unload()
+		m.stack = m.stack[:len(m.stack)-1]
+	}
+}
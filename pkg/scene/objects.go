@@ -0,0 +1,100 @@
+package scene
+
+import (
+	"remnant/pkg/physics"
+	"remnant/pkg/sdf"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// Object kinds, matching sdObject in fragment.glsl.
+const (
+	TerrainSphereKind = iota
+	SphereKind
+	BoxKind
+)
+
+// objectTexels is how many RGBA texels each object takes in the data
+// texture: position and kind, size and scale, rotation, color and emission.
+const objectTexels = 4
+
+var defaultColor = [3]float64{0.8549, 0.5843, 0.5843}
+
+// Object is one SDF primitive as laid out in the data texture.
+type Object struct {
+	Kind     int
+	Position [3]float64
+	// Size is the radius of spheres or the half extents of boxes.
+	Size     [3]float64
+	Scale    float64
+	Rotation quat.Number
+	Color    [3]float64
+	// Emission adds Color times this much light, above 1 it blooms.
+	Emission float64
+}
+
+// encodeObjects lays the objects out row by row for SetObjectsTextureData.
+func encodeObjects(objects []Object) []float32 {
+	data := make([]float32, 0, len(objects)*objectTexels*4)
+	for _, o := range objects {
+		data = append(data,
+			float32(o.Position[0]), float32(o.Position[1]), float32(o.Position[2]), float32(o.Kind),
+			float32(o.Size[0]), float32(o.Size[1]), float32(o.Size[2]), float32(o.Scale),
+			float32(o.Rotation.Imag), float32(o.Rotation.Jmag), float32(o.Rotation.Kmag), float32(o.Rotation.Real),
+			float32(o.Color[0]), float32(o.Color[1]), float32(o.Color[2]), float32(o.Emission),
+		)
+	}
+	return data
+}
+
+// legacySeed seeds the random texels SceneA and SceneB place objects from.
+const legacySeed = 5
+
+// decodeLegacyObjects turns the random 8-bit texels SceneA and SceneB
+// generate into terrain spheres, the way fragment.glsl used to place them.
+func decodeLegacyObjects(pixels []uint8, count int) []Object {
+	var objects []Object
+	for i := 0; i < count && (i+1)*4 <= len(pixels); i++ {
+		o := Object{
+			Kind:     TerrainSphereKind,
+			Size:     [3]float64{8, 8, 8},
+			Scale:    1,
+			Rotation: quat.Number{Real: 1},
+			Color:    defaultColor,
+		}
+		for c := 0; c < 3; c++ {
+			o.Position[c] = float64(pixels[i*4+c])/255*-8 + 4
+		}
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// newObjectScene mirrors compute_distance in fragment.glsl for picking and
+// collisions on the CPU.
+func newObjectScene(objects []Object) *sdf.Scene {
+	s := sdf.NewScene()
+	for _, o := range objects {
+		var shape sdf.Shape
+		origin := mat.NewVecDense(3, nil)
+		switch o.Kind {
+		case TerrainSphereKind:
+			shape = &sdf.TerrainSphere{Center: origin, Radius: o.Size[0]}
+		case SphereKind:
+			shape = &sdf.Sphere{Center: origin, Radius: o.Size[0]}
+		case BoxKind:
+			shape = &sdf.Box{Center: origin, HalfExtents: mat.NewVecDense(3, []float64{o.Size[0], o.Size[1], o.Size[2]})}
+		}
+
+		obj := s.Add(&sdf.Transform{
+			Shape:    shape,
+			Position: mat.NewVecDense(3, []float64{o.Position[0], o.Position[1], o.Position[2]}),
+			Rotation: physics.NormalizeQuaternion(o.Rotation),
+			Scale:    o.Scale,
+		})
+		obj.Color = o.Color
+		obj.Emission = o.Emission
+	}
+	return s
+}
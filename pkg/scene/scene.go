@@ -1,16 +1,55 @@
 package scene
 
 import (
+	"remnant/pkg/input"
 	"remnant/pkg/program"
+	"remnant/pkg/sdf"
 
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"gonum.org/v1/gonum/mat"
 )
 
+// Scene is driven by the Manager: Load once when it becomes part of the
+// stack, then Update and Render every frame while it is on top, and Unload
+// when it is popped or replaced.
 type Scene interface {
+	Load(program *program.Program) error
+	Update(dt float64) error
 	Render(program *program.Program) error
-	MouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey)
-	MousePositionCallback(window *glfw.Window, xpos float64, ypos float64)
-	CreateDataTexture() []uint8
+	Unload()
+	HandleInput(event input.Event)
+}
+
+// Reloadable is implemented by scenes that can apply an edited scene file
+// without being rebuilt.
+type Reloadable interface {
+	Reload(f *File)
+}
+
+// Named is implemented by scenes that can be recreated by name. Seed is what
+// their procedural content was generated from.
+type Named interface {
+	Name() string
+	Seed() int64
+}
+
+// Reference is implemented by scenes that keep a CPU mirror of the objects
+// the shader draws, for the reference renderer.
+type Reference interface {
+	World() *sdf.Scene
+}
+
+// Controllable is implemented by scenes the console can move around in:
+// jump to a position, change the field of view and fly without physics.
+type Controllable interface {
+	Teleport(position *mat.VecDense)
+	SetFOV(fov float32)
+	SetNoclip(on bool)
+}
+
+// Viewpoint is implemented by scenes rendered through a camera. Camera is
+// the one controllers and input move; ViewCamera is what is actually drawn.
+type Viewpoint interface {
 	Lights() *program.Light
 	Camera() *program.Camera
+	ViewCamera() *program.Camera
 }
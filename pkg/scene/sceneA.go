@@ -1,24 +1,28 @@
 package scene
 
 import (
-	"fmt"
 	"math/rand"
 	"remnant/internal/controller"
 	"remnant/pkg/input"
 	"remnant/pkg/program"
+	"remnant/pkg/sdf"
 	"remnant/pkg/ship"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"gonum.org/v1/gonum/mat"
 )
 
-var deltaTime = 0.0
-
 type SceneA struct {
 	*controller.Controller
-	camera *program.Camera
-	light  *program.Light
-	ship   *ship.Ship
+	window  *glfw.Window
+	program *program.Program
+	data    uint32
+	objects []Object
+	world   *sdf.Scene
+	camera  *program.Camera
+	light   *program.Light
+	ship    *ship.Ship
+	look    *input.MouseLook
 }
 
 func NewSceneA(ctr *controller.Controller) *SceneA {
@@ -27,8 +31,12 @@ func NewSceneA(ctr *controller.Controller) *SceneA {
 		light:      program.NewLight(mat.NewVecDense(3, []float64{0, 1000, 1000})),
 		camera:     program.NewCamera(mat.NewVecDense(3, []float64{0, 128, 64}), 90),
 		ship:       ship.NewShip(mat.NewVecDense(3, []float64{0, 128, 64})),
+		look:       input.NewMouseLook(0.0025),
 	}
 
+	sceneA.objects = decodeLegacyObjects(sceneA.CreateDataTexture(), 1)
+	sceneA.world = newObjectScene(sceneA.objects)
+
 	sceneA.ship.Movement = &ship.Movement{
 		Forward:  input.NewKey(glfw.KeyW),
 		Backward: input.NewKey(glfw.KeyS),
@@ -46,91 +54,58 @@ func NewSceneA(ctr *controller.Controller) *SceneA {
 	return sceneA
 }
 
-func (scene *SceneA) Render(window *glfw.Window) error {
-	// Create the shader program
-	program := program.NewProgram(window)
-	defer program.Delete()
+func (scene *SceneA) Load(program *program.Program) error {
+	scene.window = program.Window
+	scene.program = program
 
 	// Load the texture data to the GPU
-	data := program.SetObjectsTextureData(scene.CreateDataTexture())
-
-	// Set the clear color to black
-	program.SetClearColor(0.0, 0.0, 0.0, 1.0)
-
-	// initialize mouse position to middle of screen
-	window.SetCursorPos(float64(scene.Controller.ScreenWidth)/2, float64(scene.Controller.ScreenHeight)/2)
-
-	// Statistics
-	seconds := 0.0
-	fps := 0.0
-
-	w, h := window.GetSize()
-	for !window.ShouldClose() {
-
-		// CPU Events
-		glfw.PollEvents()
-
-		movement, roll := scene.ship.Movement.UpdateMovement(window, scene.camera.Dir, scene.camera.Up)
-		scene.ship.ApplyForce(movement)
-
-		scene.ship.Update(deltaTime * 2)
-		scene.camera.Pos.CopyVec(scene.ship.Position)
+	scene.data = program.SetObjectsTextureData(encodeObjects(scene.objects), objectTexels)
+	scene.look.Reset()
 
-		scene.camera.RotateZ(roll)
-		// Clear the screen
-		program.Clear()
+	return nil
+}
 
-		// Update the shader uniforms
-		program.SetTime(float32(seconds))
-		program.SetResolution(w, h)
-		program.SetData(data)
-		program.SetLight(scene.light)
-		program.SetCamera(scene.camera)
+func (scene *SceneA) Unload() {
+	scene.program.DeleteTexture(scene.data)
+}
 
-		// Draw
-		program.Draw()
+func (scene *SceneA) Update(dt float64) error {
+	movement, roll := scene.ship.Movement.UpdateMovement(scene.window, scene.camera.Dir(), scene.camera.Up())
+	scene.ship.ApplyForce(movement)
 
-		// Swap the buffers
-		window.SwapBuffers()
+	scene.ship.Update(dt * 2)
+	scene.camera.Pos.CopyVec(scene.ship.Position)
 
-		fps += 1.0
-		deltaTime = glfw.GetTime()
-		seconds += deltaTime
-		if seconds >= 1.0 {
-			window.SetTitle(fmt.Sprintf("FPS: %.2f", fps))
-			seconds = 0
-			fps = 0.0
-		}
-		glfw.SetTime(0.0)
-	}
+	scene.camera.RotateZ(roll)
+	scene.camera.Rotate(scene.look.Frame(dt))
 
 	return nil
 }
 
-func (m *SceneA) MouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
-	if button == glfw.MouseButtonLeft && action == glfw.Press {
-		fmt.Println("Left mouse button pressed")
+func (scene *SceneA) Render(program *program.Program) error {
+	program.SetData(scene.data)
+	program.SetObjectCount(len(scene.objects))
+	program.SetLight(scene.light)
+	program.SetCamera(scene.camera)
+	program.Draw()
+
+	printFlight(program.HUD, scene.camera, scene.ship.Velocity)
+	printTarget(program.HUD, scene.world, scene.ViewCamera())
+
+	if panels := program.UI; panels.Window("scene a", 280, 60, 280) {
+		panels.Bind("fov", &scene.camera.FOV, 10, 120)
+		panels.Bind("light", scene.light.Position, -2000, 2000)
+		panels.Bind("mass", &scene.ship.Mass, 0.5, 50)
+		panels.End()
 	}
 
-	if button == glfw.MouseButtonRight && action == glfw.Press {
-		fmt.Println("Right mouse button pressed")
-	}
-
-	if button == glfw.MouseButtonMiddle && action == glfw.Press {
-		fmt.Println("Middle mouse button pressed")
-	}
+	return nil
 }
 
-func (m *SceneA) MousePositionCallback(window *glfw.Window, xpos float64, ypos float64) {
-	mouseX, mouseY := xpos/float64(m.Controller.ScreenWidth), ypos/float64(m.Controller.ScreenHeight)
-	mouseX = mouseX*2 - 1
-	mouseY = (mouseY*2 - 1)
-	fmt.Println(mouseX, mouseY)
-
-	// rotate the camera based on mouse movement
-	m.camera.Rotate(mouseX, mouseY)
-
-	window.SetCursorPos(float64(m.Controller.ScreenWidth)/2, float64(m.Controller.ScreenHeight)/2)
+func (m *SceneA) HandleInput(event input.Event) {
+	if event.Kind == input.CursorPosEvent {
+		m.look.Move(event.X, event.Y)
+	}
 }
 
 func (m *SceneA) CreateDataTexture() []uint8 {
@@ -140,7 +115,7 @@ func (m *SceneA) CreateDataTexture() []uint8 {
 		RND[i] = rand.Float32()
 	}
 
-	source := rand.NewSource(5)
+	source := rand.NewSource(legacySeed)
 	r := rand.New(source)
 
 	pixels := make([]uint8, width*height*4) // 4 for RGBA
@@ -157,6 +132,34 @@ func (m *SceneA) CreateDataTexture() []uint8 {
 	return pixels
 }
 
+func (m *SceneA) Name() string {
+	return "a"
+}
+
+func (m *SceneA) Seed() int64 {
+	return legacySeed
+}
+
+func (m *SceneA) Teleport(position *mat.VecDense) {
+	m.ship.Position.CopyVec(position)
+	m.ship.Velocity.Zero()
+	m.camera.Pos.CopyVec(position)
+	if m.program != nil {
+		m.program.Temporal.Invalidate()
+	}
+}
+
+func (m *SceneA) SetFOV(fov float32) {
+	m.camera.FOV = fov
+}
+
+// SetNoclip has nothing to turn off, the ship never collides in this scene.
+func (m *SceneA) SetNoclip(on bool) {}
+
+func (m *SceneA) World() *sdf.Scene {
+	return m.world
+}
+
 func (m *SceneA) Lights() *program.Light {
 	return m.light
 }
@@ -164,3 +167,7 @@ func (m *SceneA) Lights() *program.Light {
 func (m *SceneA) Camera() *program.Camera {
 	return m.camera
 }
+
+func (m *SceneA) ViewCamera() *program.Camera {
+	return m.camera
+}
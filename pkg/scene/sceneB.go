@@ -1,11 +1,14 @@
 package scene
 
 import (
-	"fmt"
 	"math/rand"
 	"remnant/internal/controller"
+	"remnant/pkg/camera"
+	"remnant/pkg/hud"
 	"remnant/pkg/input"
+	"remnant/pkg/physics"
 	"remnant/pkg/program"
+	"remnant/pkg/sdf"
 	"remnant/pkg/ship"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -14,20 +17,79 @@ import (
 
 type SceneB struct {
 	*controller.Controller
-	camera *program.Camera
-	light  *program.Light
-	person *ship.Ship
+	window  *glfw.Window
+	program *program.Program
+	data    uint32
+	camera  *program.Camera
+	light   *program.Light
+	person  *ship.Ship
+	planets []*physics.Planet
+	objects []Object
+	world   *sdf.Scene
+
+	rig         *camera.Rig
+	freeFly     *camera.FreeFly
+	orbit       *camera.Orbit
+	cycleCamera *input.Key
+	look        *input.MouseLook
+	effects     *camera.Effects
+	boost       *input.Key
+	touching    bool
+	noclip      bool
+	clipMode    camera.Mode
+	// picked is the last object clicked, shown on the HUD
+	picked *sdf.Hit
+
+	name string
+	seed int64
 }
 
 func NewSceneB(ctr *controller.Controller) *SceneB {
+	sceneB := newSceneB(ctr,
+		program.NewLight(mat.NewVecDense(3, []float64{100, 100, 0})),
+		program.NewCamera(mat.NewVecDense(3, []float64{0, 0, -16}), 60),
+		ship.NewShip(mat.NewVecDense(3, []float64{0, 0, -16})),
+	)
+
+	sceneB.name = "b"
+	sceneB.seed = legacySeed
+	sceneB.setObjects(decodeLegacyObjects(sceneB.CreateDataTexture(), 1))
+	planet := sceneB.objects[0]
+	sceneB.planets = []*physics.Planet{
+		physics.NewPlanet(mat.NewVecDense(3, planet.Position[:]), planet.Size[0]*planet.Scale, 1.2, 4),
+	}
+
+	sceneB.person.AddForceGenerator(physics.NewLinearDrag(0.05))
+	sceneB.person.AddForceGenerator(physics.NewAtmosphericDrag(0.5, sceneB.planets...))
+
+	return sceneB
+}
+
+// newSceneB wires up the camera rig and flight controls around a ship.
+// Objects, planets and force generators are left to the caller.
+func newSceneB(ctr *controller.Controller, light *program.Light, cam *program.Camera, person *ship.Ship) *SceneB {
 	sceneB := &SceneB{
 		Controller: ctr,
-		light:      program.NewLight(mat.NewVecDense(3, []float64{100, 100, 0})),
-		camera:     program.NewCamera(mat.NewVecDense(3, []float64{0, 0, -16}), 60),
-		person:     ship.NewShip(mat.NewVecDense(3, []float64{0, 0, -16})),
+		light:      light,
+		camera:     cam,
+		person:     person,
+		world:      sdf.NewScene(),
 	}
 
-	sceneB.person.Movement = &ship.Movement{
+	sceneB.freeFly = camera.NewFreeFly(cam.Pos, cam.FOV, 8)
+	sceneB.orbit = camera.NewOrbit(person.Position, 16, cam.FOV)
+	sceneB.cycleCamera = input.NewKey(glfw.KeyC)
+	sceneB.look = input.NewMouseLook(0.0025)
+	sceneB.effects = camera.NewEffects(person.RigidBody)
+	sceneB.boost = input.NewKey(glfw.KeyLeftShift)
+
+	sceneB.rig = camera.NewRig(cam, 0.5)
+	sceneB.rig.Register(camera.CockpitMode, camera.NewCockpit(person.RigidBody, cam.FOV))
+	sceneB.rig.Register(camera.ChaseMode, camera.NewChase(person.RigidBody, 12, 3, cam.FOV))
+	sceneB.rig.Register(camera.OrbitMode, sceneB.orbit)
+	sceneB.rig.Register(camera.FreeFlyMode, sceneB.freeFly)
+
+	person.Movement = &ship.Movement{
 		Forward:  input.NewKey(glfw.KeyW),
 		Backward: input.NewKey(glfw.KeyS),
 
@@ -44,41 +106,165 @@ func NewSceneB(ctr *controller.Controller) *SceneB {
 	return sceneB
 }
 
-func (scene *SceneB) Render(program *program.Program) error {
-	movement, roll := scene.person.Movement.UpdateMovement(program.Window, scene.camera.Dir, scene.camera.Up)
+func (scene *SceneB) setObjects(objects []Object) {
+	scene.objects = objects
+	scene.world = newObjectScene(objects)
+
+	// already on the GPU, replace the texture
+	if scene.program != nil {
+		scene.program.DeleteTexture(scene.data)
+		scene.data = scene.program.SetObjectsTextureData(encodeObjects(objects), objectTexels)
+	}
+}
+
+// Reload applies an edited scene file in place: objects, lights and planets
+// change while the ship and camera carry on where they are.
+func (scene *SceneB) Reload(f *File) {
+	built := f.Build(scene.Controller)
+
+	scene.light.Position.CopyVec(built.light.Position)
+	scene.setObjects(built.objects)
+
+	scene.planets = built.planets
+	for _, g := range scene.person.Generators {
+		if drag, ok := g.(*physics.AtmosphericDrag); ok {
+			drag.Planets = scene.planets
+		}
+	}
+
+	if scene.program != nil {
+		scene.program.Temporal.Invalidate()
+	}
+}
 
-	scene.person.ApplyForce(movement)
-	//scene.person.Update(deltaTime * 2)
-	scene.camera.Pos.CopyVec(scene.person.Position)
-	scene.camera.RotateZ(roll)
+func (scene *SceneB) Load(program *program.Program) error {
+	scene.window = program.Window
+	scene.program = program
+
+	// Load the texture data to the GPU
+	scene.data = program.SetObjectsTextureData(encodeObjects(scene.objects), objectTexels)
+	scene.look.Reset()
 
 	return nil
 }
 
-func (m *SceneB) MouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
-	if button == glfw.MouseButtonLeft && action == glfw.Press {
-		fmt.Println("Left mouse button pressed")
+func (scene *SceneB) Unload() {
+	scene.program.DeleteTexture(scene.data)
+}
+
+func (scene *SceneB) Update(dt float64) error {
+	if scene.cycleCamera.Triggered(scene.window) {
+		scene.rig.Cycle()
 	}
 
-	if button == glfw.MouseButtonRight && action == glfw.Press {
-		fmt.Println("Right mouse button pressed")
+	// rotate whatever the active camera mode is looking through
+	yaw, pitch := scene.look.Frame(dt)
+	switch scene.rig.Mode() {
+	case camera.FreeFlyMode:
+		scene.freeFly.Rotate(yaw, pitch)
+	case camera.OrbitMode:
+		scene.orbit.Rotate(yaw, pitch)
+	default:
+		scene.person.Rotate(yaw, pitch, 0)
+	}
+
+	// the free-fly camera takes over the flight controls while it is active
+	if scene.rig.Mode() == camera.FreeFlyMode {
+		movement, roll := scene.person.Movement.UpdateMovement(scene.window, scene.freeFly.Direction, scene.freeFly.Up)
+		scene.freeFly.Move(movement)
+		scene.freeFly.Roll(roll)
+		scene.effects.Boosting = false
+	} else {
+		movement, roll := scene.person.Movement.UpdateMovement(scene.window, scene.person.Forward(), scene.person.Up())
+		scene.effects.Boosting = scene.boost.UpdateKeyState(scene.window)
+		if scene.effects.Boosting {
+			movement.ScaleVec(3, movement)
+		}
+		scene.person.ApplyForce(movement)
+		scene.person.Rotate(0, 0, roll)
+	}
+
+	scene.person.Update(dt * 2)
+	scene.rig.Update(dt)
+
+	// shake on the frame the ship first touches a surface, harder when fast
+	d, _ := scene.world.Distance(scene.person.Position)
+	if d < 1 && !scene.touching {
+		scene.effects.AddTrauma(0.2 + scene.person.Velocity.Norm(2)*0.05)
+	}
+	scene.touching = d < 1
+	scene.effects.Update(dt)
+
+	return nil
+}
+
+func (scene *SceneB) Render(program *program.Program) error {
+	program.SetData(scene.data)
+	program.SetObjectCount(len(scene.objects))
+	program.SetLight(scene.light)
+	view := scene.ViewCamera()
+	program.SetCamera(view)
+	program.Draw()
+
+	printFlight(program.HUD, scene.camera, scene.person.Velocity)
+	program.HUD.Printf(hud.BottomLeft, "cam %s", scene.rig.Mode())
+	printTarget(program.HUD, scene.world, view)
+	if hit := scene.picked; hit != nil {
+		p, n := hit.Point, hit.Normal
+		program.HUD.Printf(hud.Bottom, "picked #%d  at %.1f %.1f %.1f  normal %.2f %.2f %.2f",
+			hit.ID, p.AtVec(0), p.AtVec(1), p.AtVec(2), n.AtVec(0), n.AtVec(1), n.AtVec(2))
 	}
 
-	if button == glfw.MouseButtonMiddle && action == glfw.Press {
-		fmt.Println("Middle mouse button pressed")
+	if panels := program.UI; panels.Window("scene b", 280, 60, 280) {
+		panels.Label("camera %s", scene.rig.Mode())
+		panels.Struct(scene.rig.Controller())
+		panels.Struct(scene.effects)
+		panels.Bind("light", scene.light.Position, -1000, 1000)
+		panels.Label("flight")
+		panels.Bind("mass", &scene.person.Mass, 0.5, 50)
+		for _, g := range scene.person.Generators {
+			panels.Struct(g)
+		}
+		panels.End()
+	}
+
+	return nil
+}
+
+func (m *SceneB) HandleInput(event input.Event) {
+	switch event.Kind {
+	case input.MouseButtonEvent:
+		m.mouseButton(event.Window, event.Button, event.Action)
+	case input.CursorPosEvent:
+		m.look.Move(event.X, event.Y)
 	}
 }
 
-func (m *SceneB) MousePositionCallback(window *glfw.Window, xpos float64, ypos float64) {
-	mouseX, mouseY := xpos/float64(m.Controller.ScreenWidth), ypos/float64(m.Controller.ScreenHeight)
-	mouseX = mouseX*2 - 1
-	mouseY = (mouseY*2 - 1)
-	fmt.Println(mouseX, mouseY)
+func (m *SceneB) mouseButton(window *glfw.Window, button glfw.MouseButton, action glfw.Action) {
+	if button == glfw.MouseButtonLeft && action == glfw.Press {
+		// a disabled cursor has no place on screen, mouse look aims through
+		// the middle
+		x, y, w, h := 0.5, 0.5, 1, 1
+		if window.GetInputMode(glfw.CursorMode) == glfw.CursorNormal {
+			x, y = window.GetCursorPos()
+			w, h = window.GetSize()
+		}
+		m.picked = nil
+		if hit, ok := m.Pick(x, y, w, h); ok {
+			m.picked = hit
+		}
+	}
 
-	// rotate the camera based on mouse movement
-	m.camera.Rotate(mouseX, mouseY)
+	if button == glfw.MouseButtonRight && action == glfw.Press {
+		// weapon fire
+		m.effects.AddTrauma(0.15)
+	}
+}
 
-	window.SetCursorPos(float64(m.Controller.ScreenWidth)/2, float64(m.Controller.ScreenHeight)/2)
+// Pick returns the object under window position (x, y) as drawn, with the
+// speed effects applied.
+func (m *SceneB) Pick(x, y float64, w, h int) (*sdf.Hit, bool) {
+	return m.world.Pick(m.ViewCamera().ScreenRay(x, y, w, h))
 }
 
 func (m *SceneB) CreateDataTexture() []uint8 {
@@ -88,7 +274,7 @@ func (m *SceneB) CreateDataTexture() []uint8 {
 		RND[i] = rand.Float32()
 	}
 
-	source := rand.NewSource(5)
+	source := rand.NewSource(legacySeed)
 	r := rand.New(source)
 
 	pixels := make([]uint8, width*height*4) // 4 for RGBA
@@ -105,6 +291,51 @@ func (m *SceneB) CreateDataTexture() []uint8 {
 	return pixels
 }
 
+func (m *SceneB) Name() string {
+	return m.name
+}
+
+func (m *SceneB) Seed() int64 {
+	return m.seed
+}
+
+// Teleport moves the ship to position and stops it, the cameras follow.
+func (m *SceneB) Teleport(position *mat.VecDense) {
+	m.person.Position.CopyVec(position)
+	m.person.Velocity.Zero()
+	m.freeFly.Position.CopyVec(position)
+	m.rig.Snap()
+	if m.program != nil {
+		m.program.Temporal.Invalidate()
+	}
+}
+
+func (m *SceneB) SetFOV(fov float32) {
+	m.rig.SetFOV(fov)
+}
+
+// SetNoclip detaches the view from the ship: the free-fly camera takes over
+// from where the view is and flies through everything. Turning it off goes
+// back to the previous camera mode.
+func (m *SceneB) SetNoclip(on bool) {
+	if on == m.noclip {
+		return
+	}
+	m.noclip = on
+
+	if on {
+		m.clipMode = m.rig.Mode()
+		m.freeFly.Position.CopyVec(m.camera.Pos)
+		m.rig.SetMode(camera.FreeFlyMode)
+	} else {
+		m.rig.SetMode(m.clipMode)
+	}
+}
+
+func (m *SceneB) World() *sdf.Scene {
+	return m.world
+}
+
 func (m *SceneB) Lights() *program.Light {
 	return m.light
 }
@@ -112,3 +343,8 @@ func (m *SceneB) Lights() *program.Light {
 func (m *SceneB) Camera() *program.Camera {
 	return m.camera
 }
+
+// ViewCamera is the camera with speed effects applied, used for rendering.
+func (m *SceneB) ViewCamera() *program.Camera {
+	return m.effects.Apply(m.camera)
+}
@@ -0,0 +1,124 @@
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DebugMode selects a diagnostic view instead of the shaded image. The values
+// match the DEBUG_* constants in shaders/lib/debug.glsl.
+type DebugMode int
+
+const (
+	DebugOff DebugMode = iota
+	// DebugSteps is a heatmap of march steps per pixel.
+	DebugSteps
+	// DebugDistance is the hit distance on a log scale.
+	DebugDistance
+	DebugNormals
+	// DebugMaterial gives every object id its own color.
+	DebugMaterial
+	DebugShadow
+	DebugFog
+	// DebugMiss classifies every ray by how its march ended, see Outcome.
+	DebugMiss
+)
+
+var debugModeNames = []string{"off", "steps", "distance", "normals", "material", "shadow", "fog", "miss"}
+
+func (m DebugMode) String() string {
+	if int(m) < len(debugModeNames) {
+		return debugModeNames[m]
+	}
+	return fmt.Sprintf("DebugMode(%d)", int(m))
+}
+
+// Next returns the mode after m, wrapping back to DebugOff.
+func (m DebugMode) Next() DebugMode {
+	return (m + 1) % DebugMode(len(debugModeNames))
+}
+
+func ParseDebugMode(name string) (DebugMode, error) {
+	for i, n := range debugModeNames {
+		if strings.EqualFold(n, name) {
+			return DebugMode(i), nil
+		}
+	}
+	return DebugOff, fmt.Errorf("unknown debug mode %q", name)
+}
+
+// Outcome is how a march ended, matching the MARCH_* constants in
+// shaders/lib/debug.glsl.
+type Outcome int
+
+const (
+	Surface Outcome = iota
+	// Overshoot is a hit that stepped past the surface, a sign the distance
+	// estimate is too large.
+	Overshoot
+	// Escaped went past MaxDist.
+	Escaped
+	// Exhausted ran out of steps, usually grazing a surface.
+	Exhausted
+)
+
+// Hit reports whether the march ended on a surface.
+func (o Outcome) Hit() bool {
+	return o == Surface || o == Overshoot
+}
+
+// heat maps t in [0, 1] from black through red and yellow to white.
+func heat(t float64) [3]float64 {
+	t = clamp(t, 0, 1)
+	return [3]float64{clamp(3*t, 0, 1), clamp(3*t-1, 0, 1), clamp(3*t-2, 0, 1)}
+}
+
+func idColor(id int) [3]float64 {
+	var c [3]float64
+	for i, offset := range []float64{0, 0.33, 0.67} {
+		c[i] = 0.5 + 0.5*math.Cos(6.28318*(float64(id)*0.618+offset))
+	}
+	return c
+}
+
+// debugView is debug_view from shaders/lib/debug.glsl. nor may be nil for
+// misses.
+func (s *Scene) debugView(mode DebugMode, trace *Trace, nor *mat.VecDense, shadow, fog float64) [3]float64 {
+	switch mode {
+	case DebugSteps:
+		return heat(float64(trace.Steps) / float64(s.MaxSteps))
+	case DebugMiss:
+		switch trace.Outcome {
+		case Surface:
+			return [3]float64{0, 0.6, 0}
+		case Overshoot:
+			return [3]float64{1, 0, 0}
+		case Exhausted:
+			return [3]float64{1, 1, 0}
+		}
+		return [3]float64{0, 0, 0.3}
+	}
+
+	if !trace.Outcome.Hit() {
+		return [3]float64{}
+	}
+	switch mode {
+	case DebugDistance:
+		d := math.Log(1+trace.Distance) / math.Log(1+s.MaxDist)
+		return [3]float64{d, d, d}
+	case DebugNormals:
+		return [3]float64{nor.AtVec(0)*0.5 + 0.5, nor.AtVec(1)*0.5 + 0.5, nor.AtVec(2)*0.5 + 0.5}
+	case DebugMaterial:
+		return idColor(trace.ID)
+	case DebugShadow:
+		return [3]float64{shadow, shadow, shadow}
+	}
+	return [3]float64{fog, fog, fog}
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
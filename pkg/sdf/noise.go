@@ -0,0 +1,50 @@
+package sdf
+
+import "math"
+
+// Go ports of hash, noise and fbm from fragment.glsl.
+
+func fract(x float64) float64 {
+	return x - math.Floor(x)
+}
+
+func hash(x, y float64) (float64, float64) {
+	px := x*127.1 + y*311.7
+	py := x*269.5 + y*183.3
+	return -1 + 2*fract(math.Sin(px)*43758.5453123), -1 + 2*fract(math.Sin(py)*43758.5453123)
+}
+
+// Noise returns 2D gradient noise at (x, y).
+func Noise(x, y float64) float64 {
+	ix, iy := math.Floor(x), math.Floor(y)
+	fx, fy := x-ix, y-iy
+
+	ux := fx * fx * fx * (fx*(fx*6-15) + 10)
+	uy := fy * fy * fy * (fy*(fy*6-15) + 10)
+
+	gax, gay := hash(ix, iy)
+	gbx, gby := hash(ix+1, iy)
+	gcx, gcy := hash(ix, iy+1)
+	gdx, gdy := hash(ix+1, iy+1)
+
+	va := gax*fx + gay*fy
+	vb := gbx*(fx-1) + gby*fy
+	vc := gcx*fx + gcy*(fy-1)
+	vd := gdx*(fx-1) + gdy*(fy-1)
+
+	return va + ux*(vb-va) + uy*(vc-va) + ux*uy*(va-vb-vc+vd)
+}
+
+// FBM sums octaves of Noise with gain exp2(-h).
+func FBM(x, y, h float64, octaves int) float64 {
+	g := math.Exp2(-h)
+	f := 0.5
+	a := 0.5
+	t := 0.2
+	for i := 0; i < octaves; i++ {
+		t += a * Noise(f*x, f*y)
+		f *= 2
+		a *= g
+	}
+	return t
+}
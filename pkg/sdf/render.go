@@ -0,0 +1,121 @@
+package sdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// RayFunc returns the primary ray through image position (x, y), such as
+// Camera.ScreenRay.
+type RayFunc func(x, y float64) (origin, direction *mat.VecDense)
+
+// Render draws the scene on the CPU the way main() in fragment.glsl does, as
+// a reference to check the shader against. The shaded image skips the
+// post-processing chain and the stars, debug modes match the GPU views. It
+// is slow and meant for single frames.
+func (s *Scene) Render(width, height int, ray RayFunc, light *mat.VecDense, mode DebugMode) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < width; x++ {
+					origin, direction := ray(float64(x)+0.5, float64(y)+0.5)
+					img.Set(x, y, rgba(s.shade(origin, direction, light, mode), mode == DebugOff))
+				}
+			}
+		}()
+	}
+	for y := 0; y < height; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+
+	return img
+}
+
+func (s *Scene) shade(origin, direction, light *mat.VecDense, mode DebugMode) [3]float64 {
+	trace := s.Trace(origin, direction)
+	if !trace.Outcome.Hit() {
+		if mode != DebugOff {
+			return s.debugView(mode, trace, nil, 0, 0)
+		}
+		return [3]float64{0.1, 0.1, 0.1}
+	}
+
+	pos := trace.Point
+	nor := s.Normal(pos)
+
+	lig := mat.NewVecDense(3, nil)
+	lig.SubVec(light, pos)
+	lig.ScaleVec(1/lig.Norm(2), lig)
+
+	shadow := s.softShadow(pos, lig, 0.1, 2.0, 0)
+	dif := clamp(mat.Dot(nor, lig), 0, 1) * shadow
+	fog := 1 - math.Exp(-0.0001*trace.Distance)
+
+	if mode != DebugOff {
+		return s.debugView(mode, trace, nor, shadow, fog)
+	}
+
+	albedo, emission := [3]float64{1, 1, 1}, 0.0
+	if trace.ID >= 0 {
+		albedo, emission = s.Objects[trace.ID].Color, s.Objects[trace.ID].Emission
+	}
+
+	var col [3]float64
+	for i := range col {
+		c := albedo[i]*dif*0.7 + albedo[i]*emission
+		col[i] = c + (0.1-c)*fog
+	}
+	return col
+}
+
+// softShadow is calcSoftshadow from fragment.glsl without the temporal
+// dither.
+func (s *Scene) softShadow(ro, rd *mat.VecDense, mint, tmax, w float64) float64 {
+	res := 1.0
+	t := mint
+	ph := 1e10
+
+	p := mat.NewVecDense(3, nil)
+	for i := 0; i < s.ShadowSteps; i++ {
+		p.AddScaledVec(ro, t, rd)
+		h, _ := s.Distance(p)
+		y := h * h / (2 * ph)
+		d := math.Sqrt(h*h - y*y)
+		// the shader divides by zero when w is 0, skip what isn't a number
+		if r := d / (w * math.Max(0, t-y)); !math.IsNaN(r) {
+			res = math.Min(res, r)
+		}
+		ph = h
+		t += h
+		if res < 0.0001 || t > tmax {
+			break
+		}
+	}
+	return clamp(res, 0, 1)
+}
+
+// rgba converts a color to 8 bits, gamma corrected like the post chain does
+// for shaded images. Debug views skip post and are written as they are.
+func rgba(c [3]float64, gamma bool) color.RGBA {
+	channel := func(v float64) uint8 {
+		v = clamp(v, 0, 1)
+		if gamma {
+			v = math.Pow(v, 1/2.2)
+		}
+		return uint8(v*255 + 0.5)
+	}
+	return color.RGBA{channel(c[0]), channel(c[1]), channel(c[2]), 255}
+}
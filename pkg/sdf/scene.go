@@ -0,0 +1,169 @@
+package sdf
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Same limits as fragment.glsl.
+const (
+	Epsilon  = 1.0e-4
+	MaxDist  = 1024.0
+	MaxSteps = 128
+	// DefaultQuality is QUALITY in the shader at the high preset.
+	DefaultQuality = 1
+)
+
+type Object struct {
+	ID    int
+	Shape Shape
+	// Color and Emission are only used by the reference renderer.
+	Color    [3]float64
+	Emission float64
+}
+
+// Scene is the CPU side mirror of the objects the fragment shader marches.
+type Scene struct {
+	Objects  []*Object
+	MaxSteps int
+	MaxDist  float64
+	// ShadowSteps and the terrain noise octaves follow QUALITY in the
+	// shader, see SetQuality.
+	ShadowSteps int
+
+	octaves int
+}
+
+type Hit struct {
+	ID       int
+	Point    *mat.VecDense
+	Normal   *mat.VecDense
+	Distance float64
+	Steps    int
+}
+
+func NewScene() *Scene {
+	s := &Scene{
+		MaxSteps: MaxSteps,
+		MaxDist:  MaxDist,
+	}
+	s.SetQuality(DefaultQuality)
+	return s
+}
+
+// SetQuality matches the noise octaves and shadow steps to the shader
+// compiled with QUALITY set to quality.
+func (s *Scene) SetQuality(quality int) {
+	s.ShadowSteps = 16 + 16*quality
+	octaves := 4 + 4*quality
+	if octaves == s.octaves {
+		return
+	}
+	s.octaves = octaves
+	for _, obj := range s.Objects {
+		if d, ok := obj.Shape.(detailed); ok {
+			d.setOctaves(octaves)
+		}
+	}
+}
+
+func (s *Scene) Add(shape Shape) *Object {
+	if d, ok := shape.(detailed); ok {
+		d.setOctaves(s.octaves)
+	}
+	obj := &Object{ID: len(s.Objects), Shape: shape}
+	s.Objects = append(s.Objects, obj)
+	return obj
+}
+
+// Distance returns the distance to the closest object and that object's id,
+// or -1 if the scene is empty.
+func (s *Scene) Distance(p *mat.VecDense) (float64, int) {
+	minDist := s.MaxDist
+	id := -1
+	for _, obj := range s.Objects {
+		if d := obj.Shape.Distance(p); d < minDist {
+			minDist = d
+			id = obj.ID
+		}
+	}
+	return minDist, id
+}
+
+// Normal estimates the surface normal at p by central differences.
+func (s *Scene) Normal(p *mat.VecDense) *mat.VecDense {
+	n := mat.NewVecDense(3, nil)
+	q := mat.NewVecDense(3, nil)
+	for i := 0; i < 3; i++ {
+		q.CopyVec(p)
+		q.SetVec(i, p.AtVec(i)+Epsilon)
+		d1, _ := s.Distance(q)
+		q.SetVec(i, p.AtVec(i)-Epsilon)
+		d2, _ := s.Distance(q)
+		n.SetVec(i, d1-d2)
+	}
+
+	if l := n.Norm(2); l > 0 {
+		n.ScaleVec(1/l, n)
+	}
+	return n
+}
+
+// Trace is the record of one march, hit or not. Point and ID are only set
+// when Outcome is a hit.
+type Trace struct {
+	Outcome  Outcome
+	Steps    int
+	Distance float64
+	Point    *mat.VecDense
+	ID       int
+}
+
+// Trace sphere traces from origin along direction the way ray_march in
+// fragment.glsl does.
+func (s *Scene) Trace(origin, direction *mat.VecDense) *Trace {
+	ray := mat.NewVecDense(3, nil)
+	ray.CopyVec(origin)
+
+	total := 0.0
+	for i := 0; i < s.MaxSteps; i++ {
+		d, id := s.Distance(ray)
+		if d < Epsilon {
+			outcome := Surface
+			if d < -Epsilon {
+				outcome = Overshoot
+			}
+			return &Trace{Outcome: outcome, Steps: i + 1, Distance: total, Point: ray, ID: id}
+		}
+
+		ray.AddScaledVec(ray, d, direction)
+		total += d
+		if total > s.MaxDist || math.IsNaN(total) {
+			return &Trace{Outcome: Escaped, Steps: i + 1, Distance: -1, ID: -1}
+		}
+	}
+	return &Trace{Outcome: Exhausted, Steps: s.MaxSteps, Distance: -1, ID: -1}
+}
+
+// March sphere traces from origin along direction. It reports false if
+// nothing is hit within MaxSteps or MaxDist.
+func (s *Scene) March(origin, direction *mat.VecDense) (*Hit, bool) {
+	trace := s.Trace(origin, direction)
+	if !trace.Outcome.Hit() {
+		return nil, false
+	}
+	return &Hit{
+		ID:       trace.ID,
+		Point:    trace.Point,
+		Normal:   s.Normal(trace.Point),
+		Distance: trace.Distance,
+		Steps:    trace.Steps,
+	}, true
+}
+
+// Pick marches a ray, typically one from Camera.ScreenRay, and returns the
+// object it hits.
+func (s *Scene) Pick(origin, direction *mat.VecDense) (*Hit, bool) {
+	return s.March(origin, direction)
+}
@@ -0,0 +1,68 @@
+package sdf
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Shape is a signed distance function.
+type Shape interface {
+	Distance(p *mat.VecDense) float64
+}
+
+type Sphere struct {
+	Center *mat.VecDense
+	Radius float64
+}
+
+func (s *Sphere) Distance(p *mat.VecDense) float64 {
+	return distance(p, s.Center) - s.Radius
+}
+
+type Box struct {
+	Center      *mat.VecDense
+	HalfExtents *mat.VecDense
+}
+
+func (b *Box) Distance(p *mat.VecDense) float64 {
+	outside := 0.0
+	inside := math.Inf(-1)
+	for i := 0; i < 3; i++ {
+		q := math.Abs(p.AtVec(i)-b.Center.AtVec(i)) - b.HalfExtents.AtVec(i)
+		outside += math.Pow(math.Max(q, 0), 2)
+		inside = math.Max(inside, q)
+	}
+	return math.Sqrt(outside) + math.Min(inside, 0)
+}
+
+// TerrainSphere is sdSphere from fragment.glsl: a sphere whose surface is
+// pushed out by fbm noise over the local xy plane. Scene.Add and
+// Scene.SetQuality set Octaves to match the shader.
+type TerrainSphere struct {
+	Center  *mat.VecDense
+	Radius  float64
+	Octaves int
+}
+
+func (t *TerrainSphere) Distance(p *mat.VecDense) float64 {
+	x := p.AtVec(0) - t.Center.AtVec(0)
+	y := p.AtVec(1) - t.Center.AtVec(1)
+	return distance(p, t.Center) - (t.Radius + FBM(x, y, 1, t.Octaves))
+}
+
+// detailed is implemented by shapes whose detail follows QUALITY.
+type detailed interface {
+	setOctaves(n int)
+}
+
+func (t *TerrainSphere) setOctaves(n int) {
+	t.Octaves = n
+}
+
+func distance(a, b *mat.VecDense) float64 {
+	dx := a.AtVec(0) - b.AtVec(0)
+	dy := a.AtVec(1) - b.AtVec(1)
+	dz := a.AtVec(2) - b.AtVec(2)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
@@ -0,0 +1,31 @@
+package sdf
+
+import (
+	"remnant/pkg/physics"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/num/quat"
+)
+
+// Transform places a shape modelled around the origin in the world with a
+// position, rotation and uniform scale.
+type Transform struct {
+	Shape    Shape
+	Position *mat.VecDense
+	Rotation quat.Number
+	Scale    float64
+}
+
+func (t *Transform) Distance(p *mat.VecDense) float64 {
+	local := mat.NewVecDense(3, nil)
+	local.SubVec(p, t.Position)
+	local = physics.RotateVectorByQuaternion(local, quat.Conj(t.Rotation))
+	local.ScaleVec(1/t.Scale, local)
+	return t.Shape.Distance(local) * t.Scale
+}
+
+func (t *Transform) setOctaves(n int) {
+	if d, ok := t.Shape.(detailed); ok {
+		d.setOctaves(n)
+	}
+}
@@ -0,0 +1,54 @@
+package timing
+
+import (
+	"runtime"
+	"time"
+)
+
+// spinThreshold is how close to the deadline Limiter stops sleeping and
+// spins. OS sleeps routinely overshoot by a millisecond or more.
+const spinThreshold = 2 * time.Millisecond
+
+// Limiter caps the frame rate. Deadlines advance by a fixed period so the
+// average rate stays exact even when individual sleeps overshoot.
+type Limiter struct {
+	period time.Duration
+	next   time.Time
+}
+
+// NewLimiter caps at fps frames per second. Zero or less disables the cap.
+func NewLimiter(fps float64) *Limiter {
+	l := &Limiter{}
+	l.SetFPS(fps)
+	return l
+}
+
+func (l *Limiter) SetFPS(fps float64) {
+	l.period = 0
+	if fps > 0 {
+		l.period = time.Duration(float64(time.Second) / fps)
+	}
+	l.next = time.Time{}
+}
+
+// Wait blocks until the current frame's deadline.
+func (l *Limiter) Wait() {
+	if l.period == 0 {
+		return
+	}
+
+	now := time.Now()
+	if l.next.IsZero() || now.Sub(l.next) > l.period {
+		// first frame or far behind, don't try to catch up with a burst
+		l.next = now.Add(l.period)
+		return
+	}
+
+	if d := l.next.Sub(now) - spinThreshold; d > 0 {
+		time.Sleep(d)
+	}
+	for time.Now().Before(l.next) {
+		runtime.Gosched()
+	}
+	l.next = l.next.Add(l.period)
+}
@@ -0,0 +1,152 @@
+package timing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Stats collects frame times. Summaries cover the last Window frames; with
+// Record set every frame is also kept for WriteCSV.
+type Stats struct {
+	Window int
+	// HitchFactor flags a frame as a hitch when it takes this many times the
+	// rolling average.
+	HitchFactor float64
+	Record      bool
+
+	ring    []float64
+	next    int
+	frames  int
+	hitches int
+	elapsed float64
+	samples []sample
+	sorted  []float64
+}
+
+type sample struct {
+	time      float64
+	frameTime float64
+	hitch     bool
+}
+
+// Summary describes the frames in the rolling window. Times are in seconds.
+type Summary struct {
+	Frames  int
+	Min     float64
+	Avg     float64
+	Max     float64
+	P95     float64
+	P99     float64
+	FPS     float64
+	Hitches int // over the whole session
+}
+
+func NewStats(window int) *Stats {
+	return &Stats{
+		Window:      window,
+		HitchFactor: 2,
+		ring:        make([]float64, 0, window),
+	}
+}
+
+// Add records one frame that took frameTime seconds.
+func (s *Stats) Add(frameTime float64) {
+	hitch := len(s.ring) >= s.Window/4 && frameTime > s.HitchFactor*s.average()
+	if hitch {
+		s.hitches++
+	}
+
+	if len(s.ring) < s.Window {
+		s.ring = append(s.ring, frameTime)
+	} else {
+		s.ring[s.next] = frameTime
+	}
+	s.next = (s.next + 1) % s.Window
+
+	s.frames++
+	s.elapsed += frameTime
+	if s.Record {
+		s.samples = append(s.samples, sample{time: s.elapsed, frameTime: frameTime, hitch: hitch})
+	}
+}
+
+func (s *Stats) average() float64 {
+	if len(s.ring) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, t := range s.ring {
+		sum += t
+	}
+	return sum / float64(len(s.ring))
+}
+
+// Summary computes statistics over the rolling window.
+func (s *Stats) Summary() Summary {
+	if len(s.ring) == 0 {
+		return Summary{}
+	}
+
+	s.sorted = append(s.sorted[:0], s.ring...)
+	sort.Float64s(s.sorted)
+
+	avg := s.average()
+	return Summary{
+		Frames:  len(s.sorted),
+		Min:     s.sorted[0],
+		Avg:     avg,
+		Max:     s.sorted[len(s.sorted)-1],
+		P95:     percentile(s.sorted, 0.95),
+		P99:     percentile(s.sorted, 0.99),
+		FPS:     1 / avg,
+		Hitches: s.hitches,
+	}
+}
+
+// percentile uses the nearest rank of an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%.1f fps, frame ms min %.2f avg %.2f p95 %.2f p99 %.2f max %.2f, %d hitches",
+		s.FPS, s.Min*1000, s.Avg*1000, s.P95*1000, s.P99*1000, s.Max*1000, s.Hitches)
+}
+
+// WriteCSV writes every recorded frame as frame, time_s, frame_ms, hitch.
+func (s *Stats) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"frame", "time_s", "frame_ms", "hitch"})
+	for i, sm := range s.samples {
+		out.Write([]string{
+			strconv.Itoa(i),
+			strconv.FormatFloat(sm.time, 'f', 6, 64),
+			strconv.FormatFloat(sm.frameTime*1000, 'f', 3, 64),
+			strconv.FormatBool(sm.hitch),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (s *Stats) SaveCSV(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	err = s.WriteCSV(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Bind picks the widget for what ptr points to and edits it in place:
+// sliders between min and max for numbers and each component of a vector,
+// a checkbox for a bool and a text field for a string. It reports whether
+// the value changed.
+func (c *Context) Bind(label string, ptr interface{}, min, max float64) bool {
+	switch v := ptr.(type) {
+	case *float64:
+		return c.SliderFloat(label, v, min, max)
+	case *float32:
+		return c.SliderFloat32(label, v, min, max)
+	case *int:
+		return c.SliderInt(label, v, int(min), int(max))
+	case *int32:
+		n := int(*v)
+		changed := c.SliderInt(label, &n, int(min), int(max))
+		*v = int32(n)
+		return changed
+	case *bool:
+		return c.Checkbox(label, v)
+	case *string:
+		return c.TextField(label, v)
+	case *mat.VecDense:
+		if v == nil {
+			c.Label("%s: nil", label)
+			return false
+		}
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			f := v.AtVec(i)
+			if c.SliderFloat(label+" "+axisLabel(i), &f, min, max) {
+				v.SetVec(i, f)
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	c.Label("%s: %T not supported", label, ptr)
+	return false
+}
+
+var axisLabels = []string{"x", "y", "z", "w"}
+
+// axisLabel names vector component i, by number past the fourth.
+func axisLabel(i int) string {
+	if i < len(axisLabels) {
+		return axisLabels[i]
+	}
+	return strconv.Itoa(i)
+}
+
+// Struct binds the fields of the struct v points to that have a ui tag,
+// `ui:"min,max"`, labelled with the field name in lower case. Nested structs
+// and pointers to structs with tagged fields are bound with their field name
+// as a prefix. It reports whether any field changed.
+func (c *Context) Struct(v interface{}) bool {
+	return c.bindStruct("", reflect.ValueOf(v))
+}
+
+func (c *Context) bindStruct(prefix string, v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	changed := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("ui")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		label := prefix + strings.ToLower(field.Name)
+
+		if tag == "" {
+			if c.bindStruct(label+" ", v.Field(i)) {
+				changed = true
+			}
+			continue
+		}
+
+		min, max, err := parseRange(tag)
+		if err != nil {
+			c.Label("%s: %v", label, err)
+			continue
+		}
+
+		ptr := v.Field(i).Addr().Interface()
+		if f, ok := v.Field(i).Interface().(*mat.VecDense); ok {
+			ptr = f
+		}
+		if c.Bind(label, ptr, min, max) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func parseRange(tag string) (min, max float64, err error) {
+	parts := strings.Split(tag, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("ui tag %q is not min,max", tag)
+	}
+	if min, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+		return 0, 0, err
+	}
+	if max, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+		return 0, 0, err
+	}
+	return min, max, nil
+}
@@ -0,0 +1,268 @@
+package ui
+
+import (
+	glprogram "remnant/pkg/gl"
+	"remnant/pkg/input"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Colors of the panels.
+var (
+	background = [4]float32{0.08, 0.08, 0.1, 0.85}
+	titleBar   = [4]float32{0.2, 0.25, 0.4, 1}
+	widget     = [4]float32{0.2, 0.2, 0.24, 1}
+	hover      = [4]float32{0.3, 0.3, 0.36, 1}
+	accent     = [4]float32{0.35, 0.55, 0.9, 1}
+	textColor  = [4]float32{0.9, 0.9, 0.9, 1}
+)
+
+// Layout in pixels at scale 1.
+const (
+	padding = 4
+	spacing = 3
+	// labelWidth is the share of a window's width left for widget labels.
+	labelWidth = 0.4
+)
+
+// Context is an immediate-mode UI: panels and widgets are declared every
+// frame between Begin and Draw, and the widget functions report what the
+// user did with them since the last frame. Input arrives through
+// HandleEvent.
+//
+//	if ui.Window("render", 10, 10, 260) {
+//		ui.Checkbox("taa", &taa)
+//		ui.SliderFloat("exposure", &exposure, 0, 4)
+//		ui.End()
+//	}
+//
+// A nil or hidden *Context shows nothing and Window reports false.
+type Context struct {
+	Visible bool
+	// Scale multiplies the widget size on top of the window's pixel ratio.
+	Scale float32
+
+	text   *glprogram.Text
+	width  int
+	height int
+	scale  float32
+	ratio  float32
+
+	// input since the last frame
+	mouseX, mouseY float32
+	down           bool
+	pressed        bool
+	// typed holds the character and key events for the focused field, in
+	// the order they happened
+	typed []input.Event
+
+	windows map[string]*window
+	win     *window
+	x, y    float32
+
+	// active is the widget the mouse button went down on, focus the text
+	// field receiving keys
+	active string
+	focus  string
+	edit   string
+	// over is whether the mouse was over a window last frame
+	over bool
+	hot  bool
+}
+
+type window struct {
+	title      string
+	x, y, w, h float32
+	collapsed  bool
+	dragX      float32
+	dragY      float32
+}
+
+func New(pp *glprogram.Preprocessor) (*Context, error) {
+	text, err := glprogram.NewText(pp)
+	if err != nil {
+		return nil, err
+	}
+	text.Shadow = false
+
+	return &Context{
+		Scale:   1,
+		text:    text,
+		windows: map[string]*window{},
+	}, nil
+}
+
+// HandleEvent feeds the UI an input event. It reports whether the UI used
+// it, in which case it should not reach the game.
+func (c *Context) HandleEvent(e input.Event) bool {
+	if c == nil || !c.Visible {
+		return false
+	}
+
+	switch e.Kind {
+	case input.CursorPosEvent:
+		// events come in window coordinates, the UI lays out in pixels
+		c.mouseX, c.mouseY = float32(e.X)*c.ratio, float32(e.Y)*c.ratio
+		return true
+	case input.MouseButtonEvent:
+		if e.Button != glfw.MouseButtonLeft {
+			return c.over
+		}
+		if e.Action == glfw.Press {
+			c.down, c.pressed = true, true
+		} else if e.Action == glfw.Release {
+			c.down = false
+		}
+		return c.over || c.active != ""
+	case input.ScrollEvent:
+		return c.over
+	case input.CharEvent:
+		if c.focus != "" {
+			c.typed = append(c.typed, e)
+			return true
+		}
+	case input.KeyEvent:
+		if c.focus != "" {
+			if e.Action == glfw.Press || e.Action == glfw.Repeat {
+				c.typed = append(c.typed, e)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Dragging reports whether a widget is held down, for changes that are too
+// slow to apply on every step of a slider.
+func (c *Context) Dragging() bool {
+	return c != nil && c.active != "" && c.down
+}
+
+// WantsKeyboard reports whether a text field has focus, so key state should
+// not drive the game either.
+func (c *Context) WantsKeyboard() bool {
+	return c != nil && c.Visible && c.focus != ""
+}
+
+// Begin starts a frame for a window framebuffer of width by height.
+func (c *Context) Begin(width, height int, pixelRatio float64) {
+	if c == nil {
+		return
+	}
+	c.width, c.height = width, height
+	c.ratio = float32(pixelRatio)
+	c.scale = c.Scale * c.ratio
+	c.hot = false
+}
+
+// Window starts a panel with its top left corner first placed at (x, y) and
+// w pixels wide at scale 1. Panels can be dragged by their title bar and
+// collapsed by clicking it. If Window returns true the panel is open, add
+// widgets and close it with End.
+func (c *Context) Window(title string, x, y, w float32) bool {
+	if c == nil || !c.Visible {
+		return false
+	}
+
+	win, ok := c.windows[title]
+	if !ok {
+		win = &window{title: title, x: x * c.scale, y: y * c.scale}
+		c.windows[title] = win
+	}
+	win.w = w * c.scale
+	c.win = win
+
+	bar := c.rowHeight()
+	id := title + "#title"
+	if c.inside(win.x, win.y, win.w, bar) {
+		c.hot = true
+		if c.pressed {
+			c.active = id
+			win.dragX, win.dragY = c.mouseX-win.x, c.mouseY-win.y
+		}
+	}
+	if c.active == id {
+		if c.down {
+			win.x, win.y = c.mouseX-win.dragX, c.mouseY-win.dragY
+		} else {
+			// a click without moving toggles the panel
+			if c.mouseX-win.x == win.dragX && c.mouseY-win.y == win.dragY {
+				win.collapsed = !win.collapsed
+			}
+			c.active = ""
+		}
+	}
+
+	if !win.collapsed && win.h > 0 {
+		c.text.Rect(win.x, win.y, win.w, win.h, background)
+		if c.inside(win.x, win.y, win.w, win.h) {
+			c.hot = true
+		}
+	}
+	c.text.Rect(win.x, win.y, win.w, bar, titleBar)
+	marker := "- "
+	if win.collapsed {
+		marker = "+ "
+	}
+	c.label(win.x+padding*c.scale, win.y, marker+title)
+
+	c.x, c.y = win.x+padding*c.scale, win.y+bar+spacing*c.scale
+	return !win.collapsed
+}
+
+// End closes the panel opened by Window.
+func (c *Context) End() {
+	c.win.h = c.y - c.win.y + padding*c.scale
+	c.win = nil
+}
+
+// Draw draws the frame's panels into the bound framebuffer and clears the
+// input that has been handled.
+func (c *Context) Draw() {
+	if c == nil {
+		return
+	}
+
+	if c.Visible {
+		c.text.Flush(c.width, c.height)
+	}
+
+	// clicking outside every text field drops the focus
+	if c.pressed && !c.hot {
+		c.focus = ""
+	}
+	if !c.down {
+		c.active = ""
+	}
+	c.over = c.hot
+	c.pressed = false
+	c.typed = c.typed[:0]
+}
+
+// Hide closes the UI and forgets any widget being used.
+func (c *Context) Hide() {
+	c.Visible = false
+	c.active, c.focus = "", ""
+	c.down = false
+}
+
+func (c *Context) Stage(r *glprogram.Reload) error {
+	return c.text.Stage(r)
+}
+
+func (c *Context) Delete() {
+	c.text.Delete()
+}
+
+func (c *Context) rowHeight() float32 {
+	return (glprogram.GlyphHeight + 2*padding) * c.scale
+}
+
+func (c *Context) inside(x, y, w, h float32) bool {
+	return c.mouseX >= x && c.mouseX < x+w && c.mouseY >= y && c.mouseY < y+h
+}
+
+// label draws s vertically centered in a row starting at y.
+func (c *Context) label(x, y float32, s string) {
+	c.text.Draw(x, y+padding*c.scale, c.scale, textColor, s)
+}
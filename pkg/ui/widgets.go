@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	glprogram "remnant/pkg/gl"
+	"remnant/pkg/input"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Widgets go one per row of the open panel, label on the left. Each is
+// identified by its panel and label, so labels must be unique in a panel.
+
+// row lays out the next widget and returns the rectangle for its control.
+func (c *Context) row(label string) (x, y, w, h float32) {
+	h = c.rowHeight()
+	inner := c.win.w - 2*padding*c.scale
+	split := inner * labelWidth
+
+	c.label(c.x, c.y, label)
+	x, y, w = c.x+split, c.y, inner-split
+	c.y += h + spacing*c.scale
+	return x, y, w, h
+}
+
+func (c *Context) id(label string) string {
+	return c.win.title + "/" + label
+}
+
+// clicked reports whether the mouse went down inside the rectangle this
+// frame, making the widget active.
+func (c *Context) clicked(id string, x, y, w, h float32) bool {
+	if c.pressed && c.inside(x, y, w, h) {
+		c.active = id
+		return true
+	}
+	return false
+}
+
+func (c *Context) fill(x, y, w, h float32) [4]float32 {
+	if c.inside(x, y, w, h) {
+		return hover
+	}
+	return widget
+}
+
+// Label shows a line of text across the panel.
+func (c *Context) Label(format string, args ...interface{}) {
+	c.label(c.x, c.y, fmt.Sprintf(format, args...))
+	c.y += c.rowHeight() + spacing*c.scale
+}
+
+// Button reports whether it was clicked.
+func (c *Context) Button(label string) bool {
+	x, y, w, h := c.row("")
+	x, w = c.x, w+x-c.x
+
+	c.text.Rect(x, y, w, h, c.fill(x, y, w, h))
+	tw, _ := glprogram.Measure(label, c.scale)
+	c.label(x+(w-tw)/2, y, label)
+	return c.clicked(c.id(label), x, y, w, h)
+}
+
+// Checkbox toggles v when clicked and reports whether it changed.
+func (c *Context) Checkbox(label string, v *bool) bool {
+	x, y, _, h := c.row(label)
+
+	c.text.Rect(x, y, h, h, c.fill(x, y, h, h))
+	if *v {
+		inset := h / 4
+		c.text.Rect(x+inset, y+inset, h-2*inset, h-2*inset, accent)
+	}
+
+	if c.clicked(c.id(label), x, y, h, h) {
+		*v = !*v
+		return true
+	}
+	return false
+}
+
+// SliderFloat drags v between min and max and reports whether it changed.
+func (c *Context) SliderFloat(label string, v *float64, min, max float64) bool {
+	return c.slider(label, v, min, max, strconv.FormatFloat(*v, 'g', 4, 64))
+}
+
+func (c *Context) SliderFloat32(label string, v *float32, min, max float64) bool {
+	f := float64(*v)
+	changed := c.SliderFloat(label, &f, min, max)
+	*v = float32(f)
+	return changed
+}
+
+// SliderInt drags v between min and max and reports whether it changed.
+func (c *Context) SliderInt(label string, v *int, min, max int) bool {
+	f := float64(*v)
+	if !c.slider(label, &f, float64(min), float64(max), strconv.Itoa(*v)) {
+		return false
+	}
+	n := int(f + 0.5)
+	if n == *v {
+		return false
+	}
+	*v = n
+	return true
+}
+
+func (c *Context) slider(label string, v *float64, min, max float64, value string) bool {
+	x, y, w, h := c.row(label)
+	id := c.id(label)
+
+	c.text.Rect(x, y, w, h, c.fill(x, y, w, h))
+	if max > min {
+		t := (*v - min) / (max - min)
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+		c.text.Rect(x, y, w*float32(t), h, accent)
+	}
+	tw, _ := glprogram.Measure(value, c.scale)
+	c.label(x+(w-tw)/2, y, value)
+
+	// a click shorter than a frame still sets the value
+	if !c.clicked(id, x, y, w, h) && (c.active != id || !c.down) || max <= min {
+		return false
+	}
+
+	t := float64((c.mouseX - x) / w)
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	next := min + t*(max-min)
+	if next == *v {
+		return false
+	}
+	*v = next
+	return true
+}
+
+// TextField edits s. Clicking it starts editing, Enter applies the edit and
+// reports true, Escape or clicking elsewhere throws it away.
+func (c *Context) TextField(label string, s *string) bool {
+	x, y, w, h := c.row(label)
+	id := c.id(label)
+
+	if c.clicked(id, x, y, w, h) && c.focus != id {
+		c.focus, c.edit = id, *s
+	} else if c.pressed && c.focus == id && !c.inside(x, y, w, h) {
+		c.focus = ""
+	}
+
+	changed := false
+	if c.focus == id {
+		for _, e := range c.typed {
+			if e.Kind == input.CharEvent {
+				c.edit += string(e.Char)
+				continue
+			}
+			switch e.Key {
+			case glfw.KeyBackspace:
+				_, size := utf8.DecodeLastRuneInString(c.edit)
+				c.edit = c.edit[:len(c.edit)-size]
+			case glfw.KeyEnter, glfw.KeyKPEnter:
+				*s = c.edit
+				c.focus = ""
+				changed = true
+			case glfw.KeyEscape:
+				c.focus = ""
+			}
+		}
+	}
+
+	shown := *s
+	fill := c.fill(x, y, w, h)
+	if c.focus == id {
+		shown, fill = c.edit+"_", hover
+	}
+	c.text.Rect(x, y, w, h, fill)
+
+	// keep the end of long text in view
+	if max := int(w/(glprogram.GlyphWidth*c.scale)) - 1; max > 0 {
+		if runes := []rune(shown); len(runes) > max {
+			shown = string(runes[len(runes)-max:])
+		}
+	}
+	c.label(x+padding*c.scale, y, shown)
+	return changed
+}
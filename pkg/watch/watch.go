@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Watcher polls files matching glob patterns for changes. It is meant to be
+// polled from the render loop, so reloads happen on the GL thread.
+type Watcher struct {
+	Interval time.Duration
+
+	patterns []string
+	mtimes   map[string]time.Time
+	last     time.Time
+}
+
+func New(interval time.Duration) *Watcher {
+	return &Watcher{
+		Interval: interval,
+		mtimes:   make(map[string]time.Time),
+	}
+}
+
+// Add starts watching files matching the glob patterns. Files created later
+// that match are picked up too.
+func (w *Watcher) Add(patterns ...string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return err
+		}
+		w.patterns = append(w.patterns, p)
+	}
+
+	for file, mtime := range w.scan() {
+		w.mtimes[file] = mtime
+	}
+	return nil
+}
+
+// Poll returns the files created, modified or removed since the previous
+// poll. It does nothing until Interval has passed since the last scan.
+func (w *Watcher) Poll() []string {
+	now := time.Now()
+	if now.Sub(w.last) < w.Interval {
+		return nil
+	}
+	w.last = now
+
+	var changed []string
+	current := w.scan()
+	for file, mtime := range current {
+		if old, ok := w.mtimes[file]; !ok || !old.Equal(mtime) {
+			changed = append(changed, file)
+		}
+	}
+	for file := range w.mtimes {
+		if _, ok := current[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	w.mtimes = current
+
+	sort.Strings(changed)
+	return changed
+}
+
+func (w *Watcher) scan() map[string]time.Time {
+	files := make(map[string]time.Time)
+	for _, p := range w.patterns {
+		matches, _ := filepath.Glob(p)
+		for _, file := range matches {
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				files[file] = info.ModTime()
+			}
+		}
+	}
+	return files
+}
@@ -1,81 +1,46 @@
 #version 410 core
 
-uniform float time;
-uniform vec2 resolution;
-uniform sampler2D tex;
-
-uniform vec3 camera_position;
-uniform vec3 camera_direction;
-uniform vec3 camera_up;
-uniform float camera_fov;
+#include "frame.glsl"
 
-uniform vec3 light;
+uniform sampler2D tex;
+uniform int object_count;
+uniform int debug_mode;
 
 const float PI = 3.14159265;
 const float RADIAN = PI / 180.0;
 const float EPSILON = 1.0e-4;
 const int MAX_DIST = 1024;
-const int OBJ_COUNT = 1;
-const int MAX_STEPS = 128;
-const float factor = 1.0 / float(OBJ_COUNT);
+
+// OBJ_COUNT, MAX_STEPS and QUALITY are injected by the Go preprocessor,
+// these defaults only apply when the file is compiled on its own.
+#ifndef OBJ_COUNT
+#define OBJ_COUNT 64
+#endif
+#ifndef MAX_STEPS
+#define MAX_STEPS 128
+#endif
+#ifndef QUALITY
+#define QUALITY 1
+#endif
+
+const int SHADOW_STEPS = 16 + 16 * QUALITY;
+
+// Object kinds and data texture layout, see pkg/scene/objects.go
+const int TERRAIN_SPHERE = 0;
+const int SPHERE = 1;
+const int BOX = 2;
 
 out vec4 color;
 in vec2 TexCoords;
 
-float rand(vec2 c){
-	return fract(sin(dot(c.xy ,vec2(12.9898,78.233))) * 43758.5453);
-}
-
-vec2 hash( vec2 p )      // this hash is not production ready, please
-{                        // replace this by something better
-	p = vec2( dot(p,vec2(127.1,311.7)),
-			  dot(p,vec2(269.5,183.3)));
-
-	return -1.0 + 2.0*fract(sin(p)*43758.5453123);
-}
-
-vec3 noise(vec2 x )
-{
-    vec2 i = floor( x );
-    vec2 f = fract( x );
-
-    vec2 u = f*f*f*(f*(f*6.0-15.0)+10.0);
-    vec2 du = 30.0*f*f*(f*(f-2.0)+1.0);
-    
-    vec2 ga = hash( i + vec2(0.0,0.0) );
-    vec2 gb = hash( i + vec2(1.0,0.0) );
-    vec2 gc = hash( i + vec2(0.0,1.0) );
-    vec2 gd = hash( i + vec2(1.0,1.0) );
-    
-    float va = dot( ga, f - vec2(0.0,0.0) );
-    float vb = dot( gb, f - vec2(1.0,0.0) );
-    float vc = dot( gc, f - vec2(0.0,1.0) );
-    float vd = dot( gd, f - vec2(1.0,1.0) );
-
-    return vec3( va + u.x*(vb-va) + u.y*(vc-va) + u.x*u.y*(va-vb-vc+vd),   // value
-                 ga + u.x*(gb-ga) + u.y*(gc-ga) + u.x*u.y*(ga-gb-gc+gd) +  // derivatives
-                 du * (u.yx*(va-vb-vc+vd) + vec2(vb,vc) - va));
-}
-
-float fbm(vec2 x, float H )
-{    
-    float G = exp2(-H);
-    float f = 0.5;
-    float a = 0.5;
-    float t = 0.2;
-    for( int i=0; i<8; i++ )
-    {
-        t += a*noise(f*x).x;
-        f *= 2.0;
-        a *= G;
-    }
-    return t;
-}
+#include "noise.glsl"
+#include "camera.glsl"
+#include "debug.glsl"
 
 float sdSphere(vec3 p, float s) {
     vec3 n = normalize(vec3(0,1,0));
     //return dot(p,n)+5 - fbm(p.xz, 1);
-    return length(p) - (8 + fbm(p.xy, 1));
+    return length(p) - (s + fbm(p.xy, 1));
 }
 
 float sdBox(vec3 p, vec3 b) {
@@ -83,46 +48,65 @@ float sdBox(vec3 p, vec3 b) {
     return length(max(q, 0.0)) + min(max(q.x, max(q.y, q.z)), 0.0);
 }
 
-float rand(vec2 uv)
-{
-    return fract(sin(dot(uv, vec2(12.9898, 78.233))) * 43758.5453);
+vec3 rotate(vec3 v, vec4 q) {
+    return v + 2.0 * cross(q.xyz, cross(q.xyz, v) + q.w * v);
 }
 
-float noise(vec2 p)
-{
-    vec2 ip = floor(p);
-    float r00 = rand(ip);
-    float r01 = rand(ip + vec2(0, 1));
-    float r10 = rand(ip + vec2(1, 0));
-    float r11 = rand(ip + vec2(1, 1));
-    vec2 fp = smoothstep(0., 1., p - ip);
-    return mix(mix(r00, r01, fp.y), mix(r10, r11, fp.y), fp.x);
+float sdObject(vec3 p, int y) {
+    vec4 object = texelFetch(tex, ivec2(0, y), 0);
+    vec4 size = texelFetch(tex, ivec2(1, y), 0);
+    vec4 rotation = texelFetch(tex, ivec2(2, y), 0);
+
+    // into object space: undo translation, rotation (conjugate) and scale
+    vec3 q = rotate(p - object.xyz, vec4(-rotation.xyz, rotation.w)) / size.w;
+
+    int kind = int(object.w);
+    float d;
+    if (kind == BOX) {
+        d = sdBox(q, size.xyz);
+    } else if (kind == SPHERE) {
+        d = length(q) - size.x;
+    } else {
+        d = sdSphere(q, size.x);
+    }
+    return d * size.w;
 }
 
-float compute_distance(vec3 ray) {
+float compute_distance_id(vec3 ray, out int id) {
     float min_dist = MAX_DIST;
-    vec4 object = vec4(0);
-    float d = 0;
-    vec2 st = vec2(0.0);
-    vec3 pos = camera_position;
+    id = -1;
 
     for (int y = 0; y < OBJ_COUNT; y++) {
-        st = vec2(0.0, y * factor);
-        object = texture(tex, st); // Sample the texture
-		pos = (object.xyz * -8.0) + 4.0;
-		d = sdSphere(ray - pos, y % 8);
-        min_dist = min(min_dist, d);
+        if (y >= object_count) {
+            break;
+        }
+        float d = sdObject(ray, y);
+        if (d < min_dist) {
+            min_dist = d;
+            id = y;
+        }
     }
 
     return min_dist;
 }
 
+float compute_distance(vec3 ray) {
+    int id;
+    return compute_distance_id(ray, id);
+}
+
 float calcSoftshadow(vec3 ro, vec3 rd, float mint, float tmax, float w) {
     float res = 1.0;
     float t = mint;
     float ph = 1e10;
 
-    for (int i = 0; i < 32; i++) {
+    // vary the start per frame when frames are accumulated, the banding
+    // averages out into a smooth penumbra
+    if (temporal) {
+        t *= 1.0 + hash21(gl_FragCoord.xy + float(frame_index % 64) * 7.31);
+    }
+
+    for (int i = 0; i < SHADOW_STEPS; i++) {
         float h = compute_distance(ro + rd * t);
         float y = h * h / (2.0 * ph);
         float d = sqrt(h * h - y * y);
@@ -141,51 +125,73 @@ vec3 estimate_normal(vec3 p) {
     return normalize(vec3(dx, dy, dz));
 }
 
-float ray_march(vec3 ray_origin, vec3 ray_direction) {
+float ray_march(vec3 ray_origin, vec3 ray_direction, out int steps, out int result) {
     vec3 ray = ray_origin;
     float totalDistance = 0.0;
     for (int i = 0; i < MAX_STEPS; i++) {
+        steps = i + 1;
         float distanceToSurface = compute_distance(ray);
         if (distanceToSurface < EPSILON) {
+            result = distanceToSurface < -EPSILON ? MARCH_OVERSHOOT : MARCH_HIT;
             return totalDistance;
         }
         ray += distanceToSurface * ray_direction;
         totalDistance += distanceToSurface;
         if (totalDistance > MAX_DIST) {
+            result = MARCH_ESCAPED;
             return -1.0;
         }
     }
+    result = MARCH_EXHAUSTED;
     return -1.0;
 }
 
+// Sky color with sparse stars, brighter than 1 so the bloom pass catches them.
+vec3 background(vec3 rd) {
+    vec2 cell = floor(vec2(atan(rd.z, rd.x), asin(clamp(rd.y, -1.0, 1.0))) * 400.0);
+    float star = step(0.9985, hash21(cell));
+    return vec3(0.1) + star * vec3(3.0);
+}
+
 void main() {
     float aspect = resolution.y / resolution.x;
-    vec2 uv = 2.0 * TexCoords - 1.0;
+    vec2 uv = 2.0 * (TexCoords + jitter / resolution) - 1.0;
     uv.y *= aspect;
 
-    float fovFactor = tan(camera_fov * 0.5 * RADIAN);
-    vec3 forward = normalize(camera_direction);
-    vec3 right = normalize(cross(camera_up, forward));
-    vec3 up = normalize(cross(forward, right));
-
-
-    vec3 ray_direction = normalize(forward + fovFactor * uv.x * right + fovFactor * uv.y * up);
+    Basis camera = camera_basis(camera_direction, camera_up, camera_fov);
+    vec3 ray_direction = camera_ray(camera, uv);
 	//vec3 ray_direction = normalize(vec3(uv - camera_position.xy, -1.0));
 
-    float distance = ray_march(camera_position, ray_direction);
+    int steps, result;
+    float distance = ray_march(camera_position, ray_direction, steps, result);
     if (distance >= 0.0) {
         vec3 pos = camera_position + distance * ray_direction;
         vec3 nor = estimate_normal(pos);
 
         vec3 lig = normalize(light - pos);
-        float dif = clamp(dot(nor, lig), 0.0, 1.0) * calcSoftshadow(pos, lig, 0.1, 2.0, 0);
-        vec3 col = vec3(0.8549, 0.5843, 0.5843) * dif * vec3(0.7); // Simple color multiplication for demonstration
+        float shadow = calcSoftshadow(pos, lig, 0.1, 2.0, 0);
+        float dif = clamp(dot(nor, lig), 0.0, 1.0) * shadow;
+        int id;
+        compute_distance_id(pos, id);
+        float fogFactor = 1.0 - exp(-0.0001 * distance );
+
+        if (debug_mode != DEBUG_OFF) {
+            color = vec4(debug_view(debug_mode, steps, result, distance, nor, id, shadow, fogFactor), distance);
+            return;
+        }
+
+        vec4 material = texelFetch(tex, ivec2(3, max(id, 0)), 0);
+        vec3 albedo = material.rgb;
+        vec3 col = albedo * dif * vec3(0.7); // Simple color multiplication for demonstration
+        col += albedo * material.w; // emission, bright enough to bloom
 
         // fog
-        float fogFactor = 1.0 - exp(-0.0001 * distance );
         col = mix(col, vec3(0.1), fogFactor); // Simple linear interpolation for fog effect
-        color = vec4(col, 1.0);
+        // alpha carries the hit distance for temporal reprojection
+        color = vec4(col, distance);
+    } else if (debug_mode != DEBUG_OFF) {
+        color = vec4(debug_view(debug_mode, steps, result, distance, vec3(0.0), -1, 0.0, 0.0), 0.0);
     } else {
-        color = vec4(0.1); // Default color when no hit is detected
+        color = vec4(background(ray_direction), 0.0); // Default color when no hit is detected
     }
 }
@@ -9,8 +9,8 @@ in VertexData
 
 out vec4 fragColor;
 
-uniform vec2 resolution;
-uniform float time;
+#include "frame.glsl"
+
 uniform vec4 mouse; 
 
 const float PI          = 3.14159265359;
@@ -19,9 +19,8 @@ const float PI2         = 6.28318530718;
 const float MAX_DIST    = 100.;
 const float MIN_DIST    = .001;
 
-float hash21(vec2 p) {  
-    return fract(sin(dot(p, vec2(27.609, 57.583)))*43758.5453); 
-}
+#include "noise.glsl"
+
 mat2 rot(float a){
     return mat2(cos(a),sin(a),-sin(a),cos(a));
 }
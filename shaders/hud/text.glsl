@@ -0,0 +1,13 @@
+#version 410 core
+
+// The font atlas only has coverage in the red channel.
+
+uniform sampler2D atlas;
+
+in vec2 TexCoords;
+in vec4 Tint;
+out vec4 color;
+
+void main() {
+    color = vec4(Tint.rgb, Tint.a * texture(atlas, TexCoords).r);
+}
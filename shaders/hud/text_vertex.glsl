@@ -0,0 +1,19 @@
+#version 410 core
+
+// Glyph quads in window pixels with the origin in the top left corner.
+
+layout (location = 0) in vec2 position;
+layout (location = 1) in vec2 uv;
+layout (location = 2) in vec4 tint;
+
+uniform vec2 screen;
+
+out vec2 TexCoords;
+out vec4 Tint;
+
+void main() {
+    vec2 ndc = position / screen * 2.0 - 1.0;
+    gl_Position = vec4(ndc.x, -ndc.y, 0.0, 1.0);
+    TexCoords = uv;
+    Tint = tint;
+}
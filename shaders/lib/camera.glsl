@@ -0,0 +1,31 @@
+// Pinhole camera shared by the ray marcher and reprojection. Must match
+// Camera.ScreenRay in pkg/program/ray.go.
+// Include with #include "camera.glsl".
+
+struct Basis {
+    vec3 forward;
+    vec3 right;
+    vec3 up;
+    float focal;
+};
+
+Basis camera_basis(vec3 direction, vec3 up, float fov) {
+    Basis b;
+    b.forward = normalize(direction);
+    b.right = normalize(cross(up, b.forward));
+    b.up = normalize(cross(b.forward, b.right));
+    b.focal = tan(fov * 0.5 * 3.14159265 / 180.0);
+    return b;
+}
+
+// uv runs from -1 to 1 across the width, y is scaled by the aspect ratio.
+vec3 camera_ray(Basis b, vec2 uv) {
+    return normalize(b.forward + b.focal * uv.x * b.right + b.focal * uv.y * b.up);
+}
+
+// camera_project is the inverse of camera_ray for a camera relative vector
+// v. z is the depth along forward, negative behind the camera.
+vec2 camera_project(Basis b, vec3 v, out float z) {
+    z = dot(v, b.forward);
+    return vec2(dot(v, b.right), dot(v, b.up)) / (z * b.focal);
+}
@@ -0,0 +1,49 @@
+// Debug views of the ray marcher, mirrored by pkg/sdf/debug.go. Include after
+// MAX_STEPS and MAX_DIST are defined.
+
+const int DEBUG_OFF = 0;
+const int DEBUG_STEPS = 1;
+const int DEBUG_DISTANCE = 2;
+const int DEBUG_NORMALS = 3;
+const int DEBUG_MATERIAL = 4;
+const int DEBUG_SHADOW = 5;
+const int DEBUG_FOG = 6;
+const int DEBUG_MISS = 7;
+
+// How a march ended. Overshoot is a hit that stepped past the surface.
+const int MARCH_HIT = 0;
+const int MARCH_OVERSHOOT = 1;
+const int MARCH_ESCAPED = 2;
+const int MARCH_EXHAUSTED = 3;
+
+// black through red and yellow to white
+vec3 heat(float t) {
+    t = clamp(t, 0.0, 1.0);
+    return clamp(vec3(3.0 * t, 3.0 * t - 1.0, 3.0 * t - 2.0), 0.0, 1.0);
+}
+
+vec3 id_color(int id) {
+    return 0.5 + 0.5 * cos(6.28318 * (float(id) * 0.618 + vec3(0.0, 0.33, 0.67)));
+}
+
+vec3 debug_view(int mode, int steps, int result, float distance, vec3 nor, int id, float shadow, float fog) {
+    if (mode == DEBUG_STEPS) {
+        return heat(float(steps) / float(MAX_STEPS));
+    }
+    if (mode == DEBUG_MISS) {
+        if (result == MARCH_HIT) return vec3(0.0, 0.6, 0.0);
+        if (result == MARCH_OVERSHOOT) return vec3(1.0, 0.0, 0.0);
+        if (result == MARCH_EXHAUSTED) return vec3(1.0, 1.0, 0.0);
+        return vec3(0.0, 0.0, 0.3);
+    }
+
+    // the remaining views only describe surfaces
+    if (result == MARCH_ESCAPED || result == MARCH_EXHAUSTED) {
+        return vec3(0.0);
+    }
+    if (mode == DEBUG_DISTANCE) return vec3(log(1.0 + distance) / log(1.0 + float(MAX_DIST)));
+    if (mode == DEBUG_NORMALS) return nor * 0.5 + 0.5;
+    if (mode == DEBUG_MATERIAL) return id_color(id);
+    if (mode == DEBUG_SHADOW) return vec3(shadow);
+    return vec3(fog);
+}
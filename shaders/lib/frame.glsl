@@ -0,0 +1,29 @@
+// Per-frame uniform blocks, filled by pkg/program/blocks.go.
+// Include with #include "frame.glsl".
+
+layout(std140) uniform FrameData {
+    float time;
+    vec2 resolution;
+    vec2 jitter;      // sub-pixel offset of this frame, in pixels
+    int frame_index;
+    bool temporal;    // frames are accumulated, noise may vary per frame
+};
+
+layout(std140) uniform CameraData {
+    vec3 camera_position;
+    float camera_fov;
+    vec3 camera_direction;
+    vec3 camera_up;
+};
+
+// The camera of the previous frame, for reprojection.
+layout(std140) uniform PreviousCameraData {
+    vec3 previous_camera_position;
+    float previous_camera_fov;
+    vec3 previous_camera_direction;
+    vec3 previous_camera_up;
+};
+
+layout(std140) uniform LightData {
+    vec3 light;
+};
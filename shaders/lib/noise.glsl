@@ -0,0 +1,75 @@
+// Hash and noise helpers shared by the fragment shaders.
+// Include with #include "noise.glsl".
+
+#ifndef QUALITY
+#define QUALITY 1
+#endif
+
+const int FBM_OCTAVES = 4 + 4 * QUALITY;
+
+float rand(vec2 c){
+	return fract(sin(dot(c.xy ,vec2(12.9898,78.233))) * 43758.5453);
+}
+
+float hash21(vec2 p) {
+    return fract(sin(dot(p, vec2(27.609, 57.583)))*43758.5453);
+}
+
+vec2 hash( vec2 p )      // this hash is not production ready, please
+{                        // replace this by something better
+	p = vec2( dot(p,vec2(127.1,311.7)),
+			  dot(p,vec2(269.5,183.3)));
+
+	return -1.0 + 2.0*fract(sin(p)*43758.5453123);
+}
+
+// gradient noise, returns the value in x and the derivatives in yz
+vec3 noise(vec2 x )
+{
+    vec2 i = floor( x );
+    vec2 f = fract( x );
+
+    vec2 u = f*f*f*(f*(f*6.0-15.0)+10.0);
+    vec2 du = 30.0*f*f*(f*(f-2.0)+1.0);
+    
+    vec2 ga = hash( i + vec2(0.0,0.0) );
+    vec2 gb = hash( i + vec2(1.0,0.0) );
+    vec2 gc = hash( i + vec2(0.0,1.0) );
+    vec2 gd = hash( i + vec2(1.0,1.0) );
+    
+    float va = dot( ga, f - vec2(0.0,0.0) );
+    float vb = dot( gb, f - vec2(1.0,0.0) );
+    float vc = dot( gc, f - vec2(0.0,1.0) );
+    float vd = dot( gd, f - vec2(1.0,1.0) );
+
+    return vec3( va + u.x*(vb-va) + u.y*(vc-va) + u.x*u.y*(va-vb-vc+vd),   // value
+                 ga + u.x*(gb-ga) + u.y*(gc-ga) + u.x*u.y*(ga-gb-gc+gd) +  // derivatives
+                 du * (u.yx*(va-vb-vc+vd) + vec2(vb,vc) - va));
+}
+
+// value noise in [0, 1]
+float value_noise(vec2 p)
+{
+    vec2 ip = floor(p);
+    float r00 = rand(ip);
+    float r01 = rand(ip + vec2(0, 1));
+    float r10 = rand(ip + vec2(1, 0));
+    float r11 = rand(ip + vec2(1, 1));
+    vec2 fp = smoothstep(0., 1., p - ip);
+    return mix(mix(r00, r01, fp.y), mix(r10, r11, fp.y), fp.x);
+}
+
+float fbm(vec2 x, float H )
+{    
+    float G = exp2(-H);
+    float f = 0.5;
+    float a = 0.5;
+    float t = 0.2;
+    for( int i=0; i<FBM_OCTAVES; i++ )
+    {
+        t += a*noise(f*x).x;
+        f *= 2.0;
+        a *= G;
+    }
+    return t;
+}
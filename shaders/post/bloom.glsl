@@ -0,0 +1,15 @@
+#version 410 core
+
+// Adds the blurred bright pass back onto the scene.
+
+uniform sampler2D source;
+uniform sampler2D bloom;
+uniform float intensity;
+
+in vec2 TexCoords;
+out vec4 color;
+
+void main() {
+    vec3 c = texture(source, TexCoords).rgb + texture(bloom, TexCoords).rgb * intensity;
+    color = vec4(c, 1.0);
+}
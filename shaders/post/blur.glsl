@@ -0,0 +1,24 @@
+#version 410 core
+
+// Separable 9 tap gaussian blur, run once horizontally and once vertically.
+
+uniform sampler2D source;
+uniform int horizontal;
+
+in vec2 TexCoords;
+out vec4 color;
+
+const float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+
+void main() {
+    vec2 texel = 1.0 / vec2(textureSize(source, 0));
+    vec2 dir = horizontal != 0 ? vec2(texel.x, 0.0) : vec2(0.0, texel.y);
+
+    vec3 c = texture(source, TexCoords).rgb * weights[0];
+    for (int i = 1; i < 5; i++) {
+        c += texture(source, TexCoords + dir * float(i)).rgb * weights[i];
+        c += texture(source, TexCoords - dir * float(i)).rgb * weights[i];
+    }
+
+    color = vec4(c, 1.0);
+}
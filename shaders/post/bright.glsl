@@ -0,0 +1,22 @@
+#version 410 core
+
+// Bloom bright pass: keeps what is above threshold, with a soft knee so
+// the cut-off does not show as a hard edge.
+
+uniform sampler2D source;
+uniform float threshold;
+uniform float knee;
+
+in vec2 TexCoords;
+out vec4 color;
+
+void main() {
+    vec3 c = texture(source, TexCoords).rgb;
+    float brightness = max(c.r, max(c.g, c.b));
+
+    float soft = clamp(brightness - threshold + knee, 0.0, 2.0 * knee);
+    soft = soft * soft / (4.0 * knee + 1.0e-4);
+    float contribution = max(soft, brightness - threshold) / max(brightness, 1.0e-4);
+
+    color = vec4(c * contribution, 1.0);
+}
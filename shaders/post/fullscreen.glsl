@@ -0,0 +1,12 @@
+#version 410 core
+
+// A single triangle covering the screen, generated from gl_VertexID so the
+// post passes need no vertex buffer.
+
+out vec2 TexCoords;
+
+void main() {
+    vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    TexCoords = p;
+    gl_Position = vec4(p * 2.0 - 1.0, 0.0, 1.0);
+}
@@ -0,0 +1,42 @@
+#version 410 core
+
+// FXAA, the low quality preset: blurs along the local edge direction where
+// the luma contrast is high. Expects gamma corrected input.
+
+uniform sampler2D source;
+uniform float span_max;
+uniform float reduce_mul;
+uniform float reduce_min;
+
+in vec2 TexCoords;
+out vec4 color;
+
+const vec3 LUMA = vec3(0.299, 0.587, 0.114);
+
+void main() {
+    vec2 texel = 1.0 / vec2(textureSize(source, 0));
+
+    vec3 rgbM = texture(source, TexCoords).rgb;
+    float lumaNW = dot(texture(source, TexCoords + vec2(-1.0, -1.0) * texel).rgb, LUMA);
+    float lumaNE = dot(texture(source, TexCoords + vec2(1.0, -1.0) * texel).rgb, LUMA);
+    float lumaSW = dot(texture(source, TexCoords + vec2(-1.0, 1.0) * texel).rgb, LUMA);
+    float lumaSE = dot(texture(source, TexCoords + vec2(1.0, 1.0) * texel).rgb, LUMA);
+    float lumaM = dot(rgbM, LUMA);
+
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)),
+                      (lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * reduce_mul, reduce_min);
+    float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+    dir = clamp(dir * rcpDirMin, vec2(-span_max), vec2(span_max)) * texel;
+
+    vec3 rgbA = 0.5 * (texture(source, TexCoords + dir * (1.0 / 3.0 - 0.5)).rgb +
+                       texture(source, TexCoords + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 rgbB = rgbA * 0.5 + 0.25 * (texture(source, TexCoords - dir * 0.5).rgb +
+                                     texture(source, TexCoords + dir * 0.5).rgb);
+
+    float lumaB = dot(rgbB, LUMA);
+    color = vec4(lumaB < lumaMin || lumaB > lumaMax ? rgbA : rgbB, 1.0);
+}
@@ -0,0 +1,14 @@
+#version 410 core
+
+// Converts linear color for display.
+
+uniform sampler2D source;
+uniform float gamma;
+
+in vec2 TexCoords;
+out vec4 color;
+
+void main() {
+    vec3 c = texture(source, TexCoords).rgb;
+    color = vec4(pow(max(c, 0.0), vec3(1.0 / gamma)), 1.0);
+}
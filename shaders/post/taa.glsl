@@ -0,0 +1,63 @@
+#version 410 core
+
+// Temporal resolve: blends the jittered current frame with the history
+// reprojected through the previous camera, clamped to the colors around the
+// pixel so stale history cannot ghost.
+
+#include "frame.glsl"
+#include "camera.glsl"
+
+uniform sampler2D source;   // current frame, hit distance in alpha
+uniform sampler2D history;
+uniform float feedback;     // weight of the history, 0 disables accumulation
+uniform int history_valid;
+
+in vec2 TexCoords;
+out vec4 color;
+
+// reproject returns where this pixel was on the previous frame's screen.
+bool reproject(float distance, out vec2 previous) {
+    float aspect = resolution.y / resolution.x;
+    vec2 uv = 2.0 * TexCoords - 1.0;
+    uv.y *= aspect;
+
+    vec3 dir = camera_ray(camera_basis(camera_direction, camera_up, camera_fov), uv);
+    Basis before = camera_basis(previous_camera_direction, previous_camera_up, previous_camera_fov);
+
+    // misses are infinitely far away, only the rotation moves them
+    vec3 v = dir;
+    if (distance > 0.0) {
+        v = camera_position + dir * distance - previous_camera_position;
+    }
+
+    float z;
+    vec2 p = camera_project(before, v, z);
+    previous = vec2(p.x, p.y / aspect) * 0.5 + 0.5;
+    return z > 0.0 && all(greaterThanEqual(previous, vec2(0.0))) && all(lessThanEqual(previous, vec2(1.0)));
+}
+
+void main() {
+    vec4 current = texture(source, TexCoords);
+
+    vec2 previous;
+    if (history_valid == 0 || !reproject(current.a, previous)) {
+        color = current;
+        return;
+    }
+
+    // bounds of the 3x3 neighbourhood in the current frame
+    ivec2 pixel = ivec2(gl_FragCoord.xy);
+    ivec2 size = textureSize(source, 0) - 1;
+    vec3 lo = current.rgb;
+    vec3 hi = current.rgb;
+    for (int y = -1; y <= 1; y++) {
+        for (int x = -1; x <= 1; x++) {
+            vec3 c = texelFetch(source, clamp(pixel + ivec2(x, y), ivec2(0), size), 0).rgb;
+            lo = min(lo, c);
+            hi = max(hi, c);
+        }
+    }
+
+    vec3 past = clamp(texture(history, previous).rgb, lo, hi);
+    color = vec4(mix(current.rgb, past, feedback), current.a);
+}
@@ -0,0 +1,25 @@
+#version 410 core
+
+// Maps HDR color into 0..1. operator 0 is Reinhard, 1 is the ACES filmic
+// curve fit by Krzysztof Narkowicz.
+
+uniform sampler2D source;
+uniform float exposure;
+uniform int operator;
+
+in vec2 TexCoords;
+out vec4 color;
+
+vec3 reinhard(vec3 x) {
+    return x / (1.0 + x);
+}
+
+vec3 aces(vec3 x) {
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+    vec3 c = texture(source, TexCoords).rgb * exposure;
+    c = operator == 1 ? aces(c) : reinhard(c);
+    color = vec4(c, 1.0);
+}
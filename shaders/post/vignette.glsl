@@ -0,0 +1,21 @@
+#version 410 core
+
+// Darkens the corners. radius is where the falloff ends, measured from the
+// center in units of the screen height.
+
+uniform sampler2D source;
+uniform float intensity;
+uniform float radius;
+uniform float softness;
+
+in vec2 TexCoords;
+out vec4 color;
+
+void main() {
+    vec2 size = vec2(textureSize(source, 0));
+    vec2 p = (TexCoords - 0.5) * vec2(size.x / size.y, 1.0);
+
+    float v = smoothstep(radius, radius - softness, length(p));
+    vec3 c = texture(source, TexCoords).rgb * mix(1.0, v, intensity);
+    color = vec4(c, 1.0);
+}